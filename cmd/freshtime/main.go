@@ -1,32 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
-
-	"github.com/spf13/cobra"
+	"os/signal"
 
 	"github.com/hev/freshtime/internal/commands"
 )
 
 func main() {
-	root := &cobra.Command{
-		Use:     "freshtime",
-		Short:   "FreshBooks weekly time summary CLI",
-		Version: "1.0.0",
-	}
-
-	root.AddCommand(commands.SetupCmd())
-	root.AddCommand(commands.WeeklyCmd())
-	root.AddCommand(commands.ClientsCmd())
-	root.AddCommand(commands.InvoiceCmd())
-	root.AddCommand(commands.InitCmd())
-	root.AddCommand(commands.LogCmd())
-	root.AddCommand(commands.StartCmd())
-	root.AddCommand(commands.StopCmd())
-	root.AddCommand(commands.TimerStatusCmd())
-
-	if err := root.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := commands.Execute(ctx)
+	stop()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
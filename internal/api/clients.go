@@ -1,8 +1,9 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"iter"
 	"strings"
 )
 
@@ -16,26 +17,36 @@ type ClientRecord struct {
 
 // ListClients fetches all clients and returns a map of client ID to display name.
 func ListClients(c *HttpClient, accountID string) (map[int]string, error) {
-	path := fmt.Sprintf("/accounting/account/%s/users/clients", accountID)
-	raw, err := c.GetPaginated(path, "clients", nil)
-	if err != nil {
-		return nil, err
-	}
+	return ListClientsContext(context.Background(), c, accountID)
+}
 
-	result := make(map[int]string, len(raw))
-	for _, r := range raw {
-		var cr ClientRecord
-		if err := json.Unmarshal(r, &cr); err != nil {
-			continue
+// ListClientsContext is like ListClients but honors ctx.
+func ListClientsContext(ctx context.Context, c *HttpClient, accountID string) (map[int]string, error) {
+	result := make(map[int]string)
+	for cr, err := range Clients(ctx, c, accountID) {
+		if err != nil {
+			return nil, err
 		}
-		name := cr.Organization
-		if name == "" {
-			name = strings.TrimSpace(cr.FName + " " + cr.LName)
-		}
-		if name == "" {
-			name = fmt.Sprintf("Client #%d", cr.ID)
-		}
-		result[cr.ID] = name
+		result[cr.ID] = cr.DisplayName()
 	}
 	return result, nil
 }
+
+// Clients returns an iterator over all clients of an account.
+func Clients(ctx context.Context, c *HttpClient, accountID string) iter.Seq2[ClientRecord, error] {
+	path := fmt.Sprintf("/accounting/account/%s/users/clients", accountID)
+	return Paginate[ClientRecord](ctx, c, path, "clients", nil)
+}
+
+// DisplayName returns the organization name, falling back to the contact
+// name and then the ID.
+func (cr ClientRecord) DisplayName() string {
+	name := cr.Organization
+	if name == "" {
+		name = strings.TrimSpace(cr.FName + " " + cr.LName)
+	}
+	if name == "" {
+		name = fmt.Sprintf("Client #%d", cr.ID)
+	}
+	return name
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// maxLoggedBody caps how much of each request/response body is logged.
+const maxLoggedBody = 4096
+
+// DebugTransport is an http.RoundTripper that logs every request and
+// response to Logger with credentials redacted.
+type DebugTransport struct {
+	Base   http.RoundTripper
+	Logger *slog.Logger
+}
+
+// RoundTrip implements http.RoundTripper.
+func (d *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := d.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	reqBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *req.URL
+	u.RawQuery = ""
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("url", u.String()),
+	}
+	if q := redactQuery(req.URL.Query()); len(q) > 0 {
+		attrs = append(attrs, slog.String("query", q.Encode()))
+		if page := q.Get("page"); page != "" {
+			attrs = append(attrs, slog.String("page", page))
+		}
+	}
+	if len(reqBody) > 0 {
+		attrs = append(attrs, slog.String("request_body", truncate(redactBody(reqBody))))
+	}
+	ctx := req.Context()
+	d.Logger.DebugContext(ctx, "api request", attrs...)
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	latency := time.Since(start)
+	if err != nil {
+		d.Logger.ErrorContext(ctx, "api request failed", append(attrs,
+			slog.Duration("latency", latency),
+			slog.String("error", err.Error()),
+		)...)
+		return nil, err
+	}
+
+	respBody, err := drainBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	level := slog.LevelDebug
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	d.Logger.Log(ctx, level, "api response", append(attrs,
+		slog.Int("status", resp.StatusCode),
+		slog.Duration("latency", latency),
+		slog.String("response_body", truncate(redactBody(respBody))),
+	)...)
+	return resp, nil
+}
+
+func truncate(b []byte) string {
+	if len(b) <= maxLoggedBody {
+		return string(b)
+	}
+	return string(b[:maxLoggedBody]) + "…(truncated)"
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugTransportLogsRedactedExchange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "secret-access"})
+	}))
+	defer srv.Close()
+
+	origBase, origTransport := BaseURL, Transport
+	BaseURL = srv.URL
+	defer func() { BaseURL, Transport = origBase, origTransport }()
+
+	var buf bytes.Buffer
+	Transport = &DebugTransport{
+		Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	c := NewHttpClient("secret-bearer")
+	if err := c.Post("/auth/oauth/token", map[string]string{"client_secret": "secret-client"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.GetPaginated("/entries", "entries", map[string]string{"client_id": "7"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "secret-") {
+		t.Errorf("debug log contains an unredacted secret:\n%s", out)
+	}
+
+	var sawPage, sawStatus bool
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid JSON log line %q: %v", line, err)
+		}
+		if rec["page"] == "1" {
+			sawPage = true
+		}
+		if rec["status"] == float64(200) {
+			sawStatus = true
+		}
+	}
+	if !sawPage || !sawStatus {
+		t.Errorf("expected page and status attributes in log:\n%s", out)
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ApiError represents a non-2xx response from the FreshBooks API.
+type ApiError struct {
+	Status     int
+	StatusText string
+	Body       string
+	Attempts   int // number of attempts made, including retries
+
+	Errno   int          // FreshBooks error number, if reported
+	Message string       // top-level error message, if reported
+	Errors  []FieldError // individual errors parsed from the response body
+}
+
+// FieldError is a single error reported by FreshBooks, optionally tied to a field.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+	Errno   int    `json:"errno,omitempty"`
+	Object  string `json:"object,omitempty"`
+	Value   string `json:"value,omitempty"`
+}
+
+func (f FieldError) String() string {
+	if f.Field == "" {
+		return f.Message
+	}
+	return f.Field + ": " + f.Message
+}
+
+func (e *ApiError) Error() string {
+	msg := fmt.Sprintf("API error %d %s: %s", e.Status, e.StatusText, e.Detail())
+	return msg + e.attemptsSuffix()
+}
+
+// Detail returns a human-readable description of what FreshBooks reported,
+// falling back to the raw body when it could not be parsed.
+func (e *ApiError) Detail() string {
+	if len(e.Errors) > 0 {
+		parts := make([]string, len(e.Errors))
+		for i, fe := range e.Errors {
+			parts[i] = fe.String()
+		}
+		return strings.Join(parts, "; ")
+	}
+	if e.Message != "" {
+		return e.Message
+	}
+	if body := strings.TrimSpace(e.Body); body != "" {
+		return body
+	}
+	return e.StatusText
+}
+
+func (e *ApiError) attemptsSuffix() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf(" (after %d attempts)", e.Attempts)
+	}
+	return ""
+}
+
+// AuthError represents a 401 Unauthorized response.
+type AuthError struct {
+	ApiError
+}
+
+func (e *AuthError) Unwrap() error { return &e.ApiError }
+
+// ValidationError represents a request FreshBooks rejected as invalid (400 or 422).
+type ValidationError struct {
+	ApiError
+}
+
+func (e *ValidationError) Error() string {
+	return "FreshBooks rejected the request: " + e.Detail() + e.attemptsSuffix()
+}
+
+func (e *ValidationError) Unwrap() error { return &e.ApiError }
+
+// PermissionError represents a 403 response, usually a missing OAuth scope.
+type PermissionError struct {
+	ApiError
+}
+
+func (e *PermissionError) Error() string {
+	return "permission denied by FreshBooks: " + e.Detail() + e.attemptsSuffix()
+}
+
+func (e *PermissionError) Unwrap() error { return &e.ApiError }
+
+// NotFoundError represents a 404 response.
+type NotFoundError struct {
+	ApiError
+}
+
+func (e *NotFoundError) Error() string {
+	return "not found: " + e.Detail() + e.attemptsSuffix()
+}
+
+func (e *NotFoundError) Unwrap() error { return &e.ApiError }
+
+// newApiError builds the error type matching status from a response body.
+func newApiError(status int, body []byte, attempts int) error {
+	base := ApiError{
+		Status:     status,
+		StatusText: http.StatusText(status),
+		Body:       string(body),
+		Attempts:   attempts,
+	}
+	parseErrorBody(&base, body)
+
+	switch status {
+	case http.StatusUnauthorized:
+		return &AuthError{base}
+	case http.StatusForbidden:
+		return &PermissionError{base}
+	case http.StatusNotFound:
+		return &NotFoundError{base}
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return &ValidationError{base}
+	}
+	return &base
+}
+
+// parseErrorBody fills the structured fields of e from the error envelopes
+// FreshBooks uses:
+//   - Accounting: { response: { errors: [{ errno, field, message, object, value }] } }
+//   - Timetracking/projects: { errno, message, errors: { field: [messages] } }
+//   - OAuth and others: { error: "...", error_description: "..." }
+func parseErrorBody(e *ApiError, body []byte) {
+	var env struct {
+		Response struct {
+			Errors []FieldError `json:"errors"`
+		} `json:"response"`
+		Errno            int             `json:"errno"`
+		Message          string          `json:"message"`
+		Error            json.RawMessage `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+		Errors           json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil {
+		return
+	}
+
+	e.Errno = env.Errno
+	e.Message = env.Message
+	if env.ErrorDescription != "" {
+		e.Message = env.ErrorDescription
+	}
+	e.Errors = append(e.Errors, env.Response.Errors...)
+	e.Errors = append(e.Errors, parseErrorList(env.Errors)...)
+
+	var errStr string
+	if json.Unmarshal(env.Error, &errStr) == nil && errStr != "" {
+		if e.Message == "" {
+			e.Message = errStr
+		}
+	} else {
+		e.Errors = append(e.Errors, parseErrorList(env.Error)...)
+	}
+
+	if e.Errno == 0 && len(e.Errors) > 0 {
+		e.Errno = e.Errors[0].Errno
+	}
+}
+
+// parseErrorList decodes an error list given either as an array of objects or
+// as an object mapping field names to one or more messages.
+func parseErrorList(raw json.RawMessage) []FieldError {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var list []FieldError
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+
+	var byField map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &byField); err != nil {
+		return nil
+	}
+	fields := make([]string, 0, len(byField))
+	for f := range byField {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	var out []FieldError
+	for _, f := range fields {
+		var msgs []string
+		if err := json.Unmarshal(byField[f], &msgs); err != nil {
+			var msg string
+			if err := json.Unmarshal(byField[f], &msg); err != nil {
+				continue
+			}
+			msgs = []string{msg}
+		}
+		for _, m := range msgs {
+			out = append(out, FieldError{Field: f, Message: m})
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAccountingErrors(t *testing.T) {
+	body := `{"response":{"errors":[{"errno":1012,"field":"customerid","message":"Client not found.","object":"invoice","value":"99"}]}}`
+	err := newApiError(422, []byte(body), 1)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %T", err)
+	}
+	if len(verr.Errors) != 1 {
+		t.Fatalf("expected 1 field error, got %d", len(verr.Errors))
+	}
+	fe := verr.Errors[0]
+	if fe.Field != "customerid" || fe.Errno != 1012 || fe.Object != "invoice" {
+		t.Errorf("unexpected field error: %+v", fe)
+	}
+	if verr.Errno != 1012 {
+		t.Errorf("errno = %d, want 1012", verr.Errno)
+	}
+	if got := err.Error(); got != "FreshBooks rejected the request: customerid: Client not found." {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestParseTimetrackingErrors(t *testing.T) {
+	body := `{"errno":2001,"message":"Validation failed","errors":{"started_at":["must be a valid timestamp"],"duration":["must be greater than 0"]}}`
+	err := newApiError(422, []byte(body), 1)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %T", err)
+	}
+	want := "duration: must be greater than 0; started_at: must be a valid timestamp"
+	if verr.Detail() != want {
+		t.Errorf("Detail() = %q, want %q", verr.Detail(), want)
+	}
+	if verr.Errno != 2001 {
+		t.Errorf("errno = %d, want 2001", verr.Errno)
+	}
+}
+
+func TestParseOAuthError(t *testing.T) {
+	body := `{"error":"invalid_grant","error_description":"The refresh token is invalid."}`
+	err := newApiError(401, []byte(body), 1)
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected *AuthError, got %T", err)
+	}
+	if authErr.Detail() != "The refresh token is invalid." {
+		t.Errorf("Detail() = %q", authErr.Detail())
+	}
+}
+
+func TestErrorKindsByStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		check  func(error) bool
+	}{
+		{403, func(err error) bool { var e *PermissionError; return errors.As(err, &e) }},
+		{404, func(err error) bool { var e *NotFoundError; return errors.As(err, &e) }},
+		{400, func(err error) bool { var e *ValidationError; return errors.As(err, &e) }},
+		{500, func(err error) bool { _, ok := err.(*ApiError); return ok }},
+	}
+	for _, tt := range tests {
+		err := newApiError(tt.status, []byte(`{"errno":1,"message":"nope"}`), 1)
+		if !tt.check(err) {
+			t.Errorf("status %d: unexpected error type %T", tt.status, err)
+		}
+		var apiErr *ApiError
+		if !errors.As(err, &apiErr) || apiErr.Status != tt.status {
+			t.Errorf("status %d: errors.As(*ApiError) failed for %T", tt.status, err)
+		}
+	}
+}
+
+func TestUnparseableBodyFallsBackToRaw(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte("<html>gone</html>"))
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	err := NewHttpClient("test-token").Get("/missing", nil, nil)
+	var nf *NotFoundError
+	if !errors.As(err, &nf) {
+		t.Fatalf("expected *NotFoundError, got %T", err)
+	}
+	if !strings.Contains(err.Error(), "<html>gone</html>") {
+		t.Errorf("expected raw body in error, got %q", err.Error())
+	}
+}
@@ -2,12 +2,15 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
+	"sync"
+	"time"
 
 	"github.com/hev/freshtime/internal/config"
 )
@@ -15,98 +18,87 @@ import (
 // BaseURL is the base URL for the FreshBooks API. It is a var to allow overriding in tests.
 var BaseURL = "https://api.freshbooks.com"
 
-// ApiError represents a non-2xx response from the FreshBooks API.
-type ApiError struct {
-	Status     int
-	StatusText string
-	Body       string
-}
-
-func (e *ApiError) Error() string {
-	return fmt.Sprintf("API error %d %s: %s", e.Status, e.StatusText, e.Body)
-}
+// Transport is the RoundTripper used by all API requests, including token
+// requests. Nil means http.DefaultTransport. It is a var so the CLI can
+// install recording, replay or tracing transports.
+var Transport http.RoundTripper
 
-// AuthError represents a 401 Unauthorized response.
-type AuthError struct {
-	ApiError
+func newHTTPClient() *http.Client {
+	return &http.Client{Transport: Transport}
 }
 
 // HttpClient wraps authenticated requests to the FreshBooks API.
+// It is safe for concurrent use.
 type HttpClient struct {
-	token     string
-	client    *http.Client
-	onRefresh func() (string, error) // returns new token
-	retried   bool
+	tokens          TokenSource
+	client          *http.Client
+	retry           RetryPolicy
+	pageConcurrency int
+
+	throttleMu    sync.Mutex
+	throttleUntil time.Time // no request starts before this while rate limited
 }
 
 // NewHttpClient creates an HttpClient with the given bearer token.
 func NewHttpClient(token string) *HttpClient {
 	return &HttpClient{
-		token:  token,
-		client: &http.Client{},
+		tokens:          StaticTokenSource(token),
+		client:          newHTTPClient(),
+		retry:           DefaultRetryPolicy,
+		pageConcurrency: DefaultPageConcurrency,
 	}
 }
 
 // RefreshAccessToken exchanges a refresh token for new access and refresh tokens.
 func RefreshAccessToken(refreshToken string) (accessToken, newRefreshToken string, err error) {
-	payload, err := json.Marshal(map[string]string{
-		"grant_type":    "refresh_token",
-		"client_id":     os.Getenv("FRESHBOOKS_CLIENT_ID"),
-		"client_secret": os.Getenv("FRESHBOOKS_CLIENT_SECRET"),
-		"refresh_token": refreshToken,
-	})
-	if err != nil {
-		return "", "", err
-	}
+	return RefreshAccessTokenContext(context.Background(), refreshToken)
+}
 
-	resp, err := http.Post(BaseURL+"/auth/oauth/token", "application/json", bytes.NewReader(payload))
+// RefreshAccessTokenContext is like RefreshAccessToken but honors ctx.
+func RefreshAccessTokenContext(ctx context.Context, refreshToken string) (accessToken, newRefreshToken string, err error) {
+	tok, err := RefreshTokenContext(ctx, refreshToken)
 	if err != nil {
 		return "", "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return "", "", fmt.Errorf("token refresh failed (%d)", resp.StatusCode)
-	}
-
-	var result struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", "", err
-	}
-	return result.AccessToken, result.RefreshToken, nil
+	return tok.AccessToken, tok.RefreshToken, nil
 }
 
-// NewClient creates an HttpClient wired with config-aware OAuth token refresh on 401.
+// NewClient creates an HttpClient wired with config-aware OAuth token refresh.
 func NewClient(cfg *config.Config) *HttpClient {
 	c := NewHttpClient(cfg.AccessToken)
-	c.SetRefreshFunc(func() (string, error) {
-		if cfg.RefreshToken == "" {
-			return "", fmt.Errorf("no refresh token available. Run `freshtime setup` to re-authenticate")
-		}
-		accessToken, refreshToken, err := RefreshAccessToken(cfg.RefreshToken)
-		if err != nil {
-			return "", err
-		}
-		cfg.AccessToken = accessToken
-		cfg.RefreshToken = refreshToken
-		if err := config.Save(cfg); err != nil {
-			return "", fmt.Errorf("failed to save refreshed tokens: %w", err)
-		}
-		return accessToken, nil
-	})
+	c.SetTokenSource(ConfigTokenSource(cfg))
 	return c
 }
 
+// SetTokenSource sets the source of bearer tokens for subsequent requests.
+func (c *HttpClient) SetTokenSource(ts TokenSource) {
+	c.tokens = ts
+}
+
 // SetRefreshFunc sets a callback used to refresh the access token on 401.
 func (c *HttpClient) SetRefreshFunc(fn func() (string, error)) {
-	c.onRefresh = fn
+	current, _ := c.tokens.Token(context.Background())
+	c.tokens = NewRefreshingTokenSource(&Token{AccessToken: current}, func(context.Context, *Token) (*Token, error) {
+		token, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return &Token{AccessToken: token}, nil
+	})
+}
+
+// SetRetryPolicy sets the retry policy used for idempotent requests.
+func (c *HttpClient) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
 }
 
 // Get performs an authenticated GET request and decodes the JSON response into dest.
 func (c *HttpClient) Get(path string, params map[string]string, dest any) error {
+	return c.GetContext(context.Background(), path, params, dest)
+}
+
+// GetContext is like Get but honors ctx.
+func (c *HttpClient) GetContext(ctx context.Context, path string, params map[string]string, dest any) error {
 	u, err := url.Parse(BaseURL + path)
 	if err != nil {
 		return err
@@ -117,7 +109,7 @@ func (c *HttpClient) Get(path string, params map[string]string, dest any) error
 	}
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return err
 	}
@@ -126,22 +118,46 @@ func (c *HttpClient) Get(path string, params map[string]string, dest any) error
 
 // Post performs an authenticated POST request.
 func (c *HttpClient) Post(path string, body any, dest any) error {
-	return c.mutate("POST", path, body, dest)
+	return c.PostContext(context.Background(), path, body, dest)
+}
+
+// PostContext is like Post but honors ctx.
+func (c *HttpClient) PostContext(ctx context.Context, path string, body any, dest any) error {
+	return c.mutate(ctx, "POST", path, body, dest)
 }
 
 // Put performs an authenticated PUT request.
 func (c *HttpClient) Put(path string, body any, dest any) error {
-	return c.mutate("PUT", path, body, dest)
+	return c.PutContext(context.Background(), path, body, dest)
+}
+
+// PutContext is like Put but honors ctx.
+func (c *HttpClient) PutContext(ctx context.Context, path string, body any, dest any) error {
+	return c.mutate(ctx, "PUT", path, body, dest)
+}
+
+// Delete performs an authenticated DELETE request.
+func (c *HttpClient) Delete(path string) error {
+	return c.DeleteContext(context.Background(), path)
 }
 
-func (c *HttpClient) mutate(method, path string, body any, dest any) error {
+// DeleteContext is like Delete but honors ctx.
+func (c *HttpClient) DeleteContext(ctx context.Context, path string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	return c.doJSON(req, nil)
+}
+
+func (c *HttpClient) mutate(ctx context.Context, method, path string, body any, dest any) error {
 	u := BaseURL + path
 	data, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
@@ -149,72 +165,115 @@ func (c *HttpClient) mutate(method, path string, body any, dest any) error {
 }
 
 func (c *HttpClient) doJSON(req *http.Request, dest any) error {
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
+	policy := c.retry
+	if !isIdempotent(req.Method) {
+		policy = NoRetry
 	}
-	defer resp.Body.Close()
+	start := time.Now()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
+	refreshed := false
 
-	if resp.StatusCode == 401 && !c.retried && c.onRefresh != nil {
-		c.retried = true
-		newToken, refreshErr := c.onRefresh()
-		if refreshErr != nil {
-			return &AuthError{ApiError{401, "Unauthorized", "Session expired. Run `freshtime setup` to re-authenticate."}}
+	for attempt := 1; ; attempt++ {
+		if err := c.waitThrottle(req.Context()); err != nil {
+			return err
+		}
+		token, err := c.tokens.Token(req.Context())
+		if err != nil {
+			return err
 		}
-		c.token = newToken
-		return c.doJSON(req, dest)
+		resp, respBody, err := c.send(req, token)
+		if err != nil {
+			if delay, ok := policy.next(attempt, start, 0); ok && req.Context().Err() == nil {
+				if err := sleepContext(req.Context(), delay); err != nil {
+					return err
+				}
+				continue
+			}
+			if attempt > 1 {
+				return fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return err
+		}
+
+		if resp.StatusCode == 401 && !refreshed {
+			refreshed = true
+			_, refreshErr := c.tokens.Refresh(req.Context(), token)
+			if refreshErr == nil {
+				attempt--
+				continue
+			}
+			if !errors.Is(refreshErr, errCannotRefresh) {
+				return &AuthError{ApiError{
+					Status:     401,
+					StatusText: "Unauthorized",
+					Body:       "Session expired. Run `freshtime setup` to re-authenticate.",
+					Attempts:   attempt,
+				}}
+			}
+		}
+
+		if resp.StatusCode == 401 {
+			return newApiError(resp.StatusCode, respBody, attempt)
+		}
+		if isRetryableStatus(resp.StatusCode) {
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if resp.StatusCode == http.StatusTooManyRequests {
+				c.throttle(retryAfter)
+			}
+			if delay, ok := policy.next(attempt, start, retryAfter); ok {
+				if err := sleepContext(req.Context(), delay); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return newApiError(resp.StatusCode, respBody, attempt)
+		}
+
+		if dest != nil {
+			return json.Unmarshal(respBody, dest)
+		}
+		return nil
 	}
+}
 
-	if resp.StatusCode == 401 {
-		return &AuthError{ApiError{401, "Unauthorized", string(respBody)}}
+// send performs a single attempt of req, rewinding the body so the request
+// can be repeated, and returns the response with its body fully read.
+func (c *HttpClient) send(req *http.Request, token string) (*http.Response, []byte, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Body = body
 	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &ApiError{resp.StatusCode, resp.Status, string(respBody)}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer resp.Body.Close()
 
-	if dest != nil {
-		return json.Unmarshal(respBody, dest)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil
+	return resp, respBody, nil
 }
 
 // GetPaginated fetches all pages for a paginated endpoint.
 // resultKey is the JSON key containing the array of results.
 func (c *HttpClient) GetPaginated(path, resultKey string, params map[string]string) ([]json.RawMessage, error) {
-	var allResults []json.RawMessage
-	page := 1
-	totalPages := 1
-
-	for page <= totalPages {
-		p := make(map[string]string)
-		for k, v := range params {
-			p[k] = v
-		}
-		p["page"] = fmt.Sprintf("%d", page)
-		p["per_page"] = "100"
-
-		var raw map[string]json.RawMessage
-		if err := c.Get(path, p, &raw); err != nil {
-			return nil, err
-		}
-
-		items, pages := extractPage(raw, resultKey)
-		allResults = append(allResults, items...)
-		if pages > 0 {
-			totalPages = pages
-		}
-		page++
-	}
+	return c.GetPaginatedContext(context.Background(), path, resultKey, params)
+}
 
-	return allResults, nil
+// GetPaginatedContext is like GetPaginated but honors ctx. It loads every
+// page into memory; use Paginate to stream typed records instead.
+func (c *HttpClient) GetPaginatedContext(ctx context.Context, path, resultKey string, params map[string]string) ([]json.RawMessage, error) {
+	return Collect(Paginate[json.RawMessage](ctx, c, path, resultKey, params))
 }
 
 // extractPage handles the two FreshBooks response shapes:
@@ -1,10 +1,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestGet(t *testing.T) {
@@ -94,6 +100,55 @@ func TestPut(t *testing.T) {
 	}
 }
 
+func TestDelete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/items/7" {
+			t.Errorf("expected DELETE /items/7, got %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	c := NewHttpClient("test-token")
+	if err := c.Delete("/items/7"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdateTimeEntrySendsOnlyChanges(t *testing.T) {
+	var got map[string]map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/timetracking/business/1/time_entries/7" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(map[string]any{"time_entry": map[string]any{"id": 7, "note": "new"}})
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	note := "new"
+	entry := TimeEntry{ID: 7, Duration: 3600, StartedAt: "2026-02-10T15:00:00Z", LocalStartedAt: "2026-02-10T07:00:00", Note: "old", ClientID: 3}
+	updated, err := UpdateTimeEntry(NewHttpClient("test-token"), 1, entry, TimeEntryUpdate{Note: &note})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Note != "new" {
+		t.Errorf("updated note = %q", updated.Note)
+	}
+	want := map[string]any{"note": "new", "started_at": "2026-02-10T15:00:00Z", "local_started_at": "2026-02-10T07:00:00", "duration": float64(3600), "is_logged": true}
+	if fmt.Sprint(got["time_entry"]) != fmt.Sprint(want) {
+		t.Errorf("sent %v, want %v", got["time_entry"], want)
+	}
+}
+
 func TestApiError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(500)
@@ -106,6 +161,7 @@ func TestApiError(t *testing.T) {
 	defer func() { BaseURL = origBase }()
 
 	c := NewHttpClient("test-token")
+	c.SetRetryPolicy(fastRetry)
 	var result map[string]string
 	err := c.Get("/fail", nil, &result)
 	if err == nil {
@@ -118,6 +174,115 @@ func TestApiError(t *testing.T) {
 	if apiErr.Status != 500 {
 		t.Errorf("expected status 500, got %d", apiErr.Status)
 	}
+	if apiErr.Attempts != fastRetry.MaxAttempts {
+		t.Errorf("expected %d attempts, got %d", fastRetry.MaxAttempts, apiErr.Attempts)
+	}
+	if !strings.Contains(apiErr.Error(), "after 3 attempts") {
+		t.Errorf("error should report attempts, got %q", apiErr.Error())
+	}
+}
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetryThenSucceed(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(429)
+			return
+		}
+		if calls == 2 {
+			w.WriteHeader(503)
+			return
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["key"] != "value" {
+			t.Errorf("body not replayed on retry, got %v", body)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"ok": "true"})
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	c := NewHttpClient("test-token")
+	c.SetRetryPolicy(fastRetry)
+	var result map[string]string
+	if err := c.Put("/update", map[string]string{"key": "value"}, &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestNoRetryForPost(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(503)
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	c := NewHttpClient("test-token")
+	c.SetRetryPolicy(fastRetry)
+	err := c.Post("/create", map[string]string{}, nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if calls != 1 {
+		t.Errorf("POST should not be retried, got %d calls", calls)
+	}
+}
+
+func TestRetryElapsedBudget(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxElapsed: 500 * time.Millisecond}
+	if _, ok := p.next(1, time.Now(), 0); ok {
+		t.Error("expected no retry when delay exceeds elapsed budget")
+	}
+	if _, ok := p.next(1, time.Now(), 100*time.Millisecond); !ok {
+		t.Error("expected retry when Retry-After fits the budget")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{"Mon, 09 Feb 2026 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 09 Feb 2026 11:00:00 GMT", 0},
+	}
+	for _, tt := range tests {
+		got := parseRetryAfter(tt.input, now)
+		if got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 1; attempt <= 8; attempt++ {
+		d := p.backoff(attempt)
+		if d < 50*time.Millisecond || d > time.Second {
+			t.Errorf("backoff(%d) = %v, out of bounds", attempt, d)
+		}
+	}
 }
 
 func TestAuthError(t *testing.T) {
@@ -269,3 +434,106 @@ func TestExtractPageAccounting(t *testing.T) {
 		t.Errorf("expected 5 pages, got %d", pages)
 	}
 }
+
+func TestGetContextCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(429)
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := NewHttpClient("test-token")
+	start := time.Now()
+	_, err := c.GetPaginatedContext(ctx, "/entries", "entries", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("request did not stop at deadline")
+	}
+}
+
+func TestGetPaginatedParallelKeepsOrder(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		page := r.URL.Query().Get("page")
+		if page != "1" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"entries":[{"page":%s}],"meta":{"pages":8}}`, page)
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	c := NewHttpClient("test-token")
+	c.SetPageConcurrency(3)
+	results, err := c.GetPaginated("/entries", "entries", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 8 {
+		t.Fatalf("expected 8 results, got %d", len(results))
+	}
+	for i, r := range results {
+		want := fmt.Sprintf(`{"page":%d}`, i+1)
+		if string(r) != want {
+			t.Errorf("result %d = %s, want %s", i, r, want)
+		}
+	}
+	if m := maxInFlight.Load(); m < 2 || m > 3 {
+		t.Errorf("max in-flight requests = %d, want 2..3", m)
+	}
+}
+
+func TestGetPaginatedParallelError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "3" {
+			w.WriteHeader(404)
+			return
+		}
+		fmt.Fprint(w, `{"entries":[{}],"meta":{"pages":5}}`)
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	c := NewHttpClient("test-token")
+	_, err := c.GetPaginated("/entries", "entries", nil)
+	var apiErr *ApiError
+	if !errors.As(err, &apiErr) || apiErr.Status != 404 {
+		t.Fatalf("expected 404 ApiError, got %v", err)
+	}
+}
+
+func TestThrottleSharedAcrossRequests(t *testing.T) {
+	c := NewHttpClient("test-token")
+	c.throttle(30 * time.Millisecond)
+	start := time.Now()
+	if err := c.waitThrottle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("waitThrottle returned before the throttle window passed")
+	}
+}
@@ -1,6 +1,9 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // Identity holds the account and business IDs for a FreshBooks user.
 type Identity struct {
@@ -8,12 +11,32 @@ type Identity struct {
 	BusinessID int
 }
 
+// Business is a FreshBooks business the current user is a member of.
+type Business struct {
+	ID        int
+	AccountID string
+	Name      string
+}
+
+// User is the FreshBooks user that owns the access token.
+type User struct {
+	ID         int
+	FirstName  string
+	LastName   string
+	Email      string
+	Businesses []Business
+}
+
 type meResponse struct {
 	Response struct {
-		ID                  int `json:"id"`
+		ID                  int    `json:"id"`
+		FirstName           string `json:"first_name"`
+		LastName            string `json:"last_name"`
+		Email               string `json:"email"`
 		BusinessMemberships []struct {
 			Business struct {
 				ID        int    `json:"id"`
+				Name      string `json:"name"`
 				AccountID string `json:"account_id"`
 			} `json:"business"`
 		} `json:"business_memberships"`
@@ -22,19 +45,65 @@ type meResponse struct {
 
 // GetIdentity fetches the current user's identity from the FreshBooks API.
 func GetIdentity(c *HttpClient) (*Identity, error) {
+	return GetIdentityContext(context.Background(), c)
+}
+
+// GetIdentityContext is like GetIdentity but honors ctx.
+func GetIdentityContext(ctx context.Context, c *HttpClient) (*Identity, error) {
+	businesses, err := ListBusinessesContext(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	first := businesses[0]
+	return &Identity{
+		AccountID:  first.AccountID,
+		BusinessID: first.ID,
+	}, nil
+}
+
+// GetCurrentUser fetches the user that owns the client's access token.
+func GetCurrentUser(c *HttpClient) (*User, error) {
+	return GetCurrentUserContext(context.Background(), c)
+}
+
+// GetCurrentUserContext is like GetCurrentUser but honors ctx.
+func GetCurrentUserContext(ctx context.Context, c *HttpClient) (*User, error) {
 	var data meResponse
-	if err := c.Get("/auth/api/v1/users/me", nil, &data); err != nil {
+	if err := c.GetContext(ctx, "/auth/api/v1/users/me", nil, &data); err != nil {
 		return nil, err
 	}
 
-	memberships := data.Response.BusinessMemberships
-	if len(memberships) == 0 {
-		return nil, fmt.Errorf("no business memberships found on this account")
+	me := data.Response
+	user := &User{
+		ID:        me.ID,
+		FirstName: me.FirstName,
+		LastName:  me.LastName,
+		Email:     me.Email,
+	}
+	for _, m := range me.BusinessMemberships {
+		user.Businesses = append(user.Businesses, Business{
+			ID:        m.Business.ID,
+			AccountID: m.Business.AccountID,
+			Name:      m.Business.Name,
+		})
 	}
+	return user, nil
+}
 
-	first := memberships[0]
-	return &Identity{
-		AccountID:  first.Business.AccountID,
-		BusinessID: first.Business.ID,
-	}, nil
+// ListBusinesses returns every business the current user is a member of.
+func ListBusinesses(c *HttpClient) ([]Business, error) {
+	return ListBusinessesContext(context.Background(), c)
+}
+
+// ListBusinessesContext is like ListBusinesses but honors ctx.
+func ListBusinessesContext(ctx context.Context, c *HttpClient) ([]Business, error) {
+	user, err := GetCurrentUserContext(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Businesses) == 0 {
+		return nil, fmt.Errorf("no business memberships found on this account")
+	}
+	return user.Businesses, nil
 }
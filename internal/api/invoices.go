@@ -1,14 +1,17 @@
 package api
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // InvoiceLine represents a line item on an invoice.
 type InvoiceLine struct {
-	Type        int            `json:"type"`
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Qty         string         `json:"qty"`
-	UnitCost    InvoiceAmount  `json:"unit_cost"`
+	Type        int           `json:"type"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Qty         string        `json:"qty"`
+	UnitCost    InvoiceAmount `json:"unit_cost"`
 }
 
 // InvoiceAmount holds a monetary amount with currency code.
@@ -57,9 +60,14 @@ type shareLinkResp struct {
 
 // CreateInvoice creates a new invoice in FreshBooks.
 func CreateInvoice(c *HttpClient, accountID string, req *CreateInvoiceRequest) (*InvoiceResponse, error) {
+	return CreateInvoiceContext(context.Background(), c, accountID, req)
+}
+
+// CreateInvoiceContext is like CreateInvoice but honors ctx.
+func CreateInvoiceContext(ctx context.Context, c *HttpClient, accountID string, req *CreateInvoiceRequest) (*InvoiceResponse, error) {
 	path := fmt.Sprintf("/accounting/account/%s/invoices/invoices", accountID)
 	var resp createInvoiceResp
-	if err := c.Post(path, req, &resp); err != nil {
+	if err := c.PostContext(ctx, path, req, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.Response.Result.Invoice, nil
@@ -67,9 +75,14 @@ func CreateInvoice(c *HttpClient, accountID string, req *CreateInvoiceRequest) (
 
 // GetShareLink fetches the share link for an invoice.
 func GetShareLink(c *HttpClient, accountID string, invoiceID int) (string, error) {
+	return GetShareLinkContext(context.Background(), c, accountID, invoiceID)
+}
+
+// GetShareLinkContext is like GetShareLink but honors ctx.
+func GetShareLinkContext(ctx context.Context, c *HttpClient, accountID string, invoiceID int) (string, error) {
 	path := fmt.Sprintf("/accounting/account/%s/invoices/invoices/%d/share_link", accountID, invoiceID)
 	var resp shareLinkResp
-	if err := c.Get(path, nil, &resp); err != nil {
+	if err := c.GetContext(ctx, path, nil, &resp); err != nil {
 		return "", err
 	}
 	return resp.Response.Result.ShareLink, nil
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strconv"
+	"time"
+)
+
+// perPage is the page size requested from paginated endpoints.
+const perPage = 100
+
+// DefaultPageConcurrency is the number of pages fetched in parallel by
+// clients created after it is set.
+var DefaultPageConcurrency = 4
+
+// SetPageConcurrency sets how many pages are fetched in parallel while paginating.
+// Values below 1 are treated as 1 (sequential).
+func (c *HttpClient) SetPageConcurrency(n int) {
+	c.pageConcurrency = max(n, 1)
+}
+
+// fetchPage fetches a single page and returns its items and the total page count.
+func (c *HttpClient) fetchPage(ctx context.Context, path, resultKey string, params map[string]string, page int) ([]json.RawMessage, int, error) {
+	p := make(map[string]string, len(params)+2)
+	for k, v := range params {
+		p[k] = v
+	}
+	p["page"] = strconv.Itoa(page)
+	p["per_page"] = strconv.Itoa(perPage)
+
+	var raw map[string]json.RawMessage
+	if err := c.GetContext(ctx, path, p, &raw); err != nil {
+		return nil, 0, err
+	}
+	items, pages := extractPage(raw, resultKey)
+	return items, pages, nil
+}
+
+// Paginate returns an iterator over every record of a paginated endpoint,
+// decoded into T. Pages are fetched lazily: after the first page reveals the
+// page count, up to the client's page concurrency pages are prefetched ahead
+// of the consumer, and records are yielded in page order. Breaking out of the
+// loop stops further requests.
+//
+// A request error is yielded once and ends the iteration. A record that
+// cannot be decoded is yielded as an error; iteration continues if the
+// consumer keeps going.
+func Paginate[T any](ctx context.Context, c *HttpClient, path, resultKey string, params map[string]string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		items, totalPages, err := c.fetchPage(ctx, path, resultKey, params, 1)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		if !yieldDecoded(items, resultKey, 1, yield) {
+			return
+		}
+
+		type pageResult struct {
+			items []json.RawMessage
+			err   error
+		}
+		var pending []chan pageResult
+		ctx, cancel := context.WithCancel(ctx)
+		defer func() {
+			// Stop prefetches and wait for them so no request outlives the loop.
+			cancel()
+			for _, ch := range pending {
+				<-ch
+			}
+		}()
+		next := 2
+		launch := func() {
+			ch := make(chan pageResult, 1)
+			page := next
+			next++
+			go func() {
+				items, _, err := c.fetchPage(ctx, path, resultKey, params, page)
+				ch <- pageResult{items, err}
+			}()
+			pending = append(pending, ch)
+		}
+		for next <= totalPages && len(pending) < max(c.pageConcurrency, 1) {
+			launch()
+		}
+
+		for page := 2; len(pending) > 0; page++ {
+			r := <-pending[0]
+			pending = pending[1:]
+			if r.err != nil {
+				yield(zero, r.err)
+				return
+			}
+			if next <= totalPages {
+				launch()
+			}
+			if !yieldDecoded(r.items, resultKey, page, yield) {
+				return
+			}
+		}
+	}
+}
+
+// yieldDecoded decodes each raw item into T and yields it. It reports
+// whether the consumer wants more.
+func yieldDecoded[T any](items []json.RawMessage, resultKey string, page int, yield func(T, error) bool) bool {
+	for i, raw := range items {
+		var v T
+		if err := json.Unmarshal(raw, &v); err != nil {
+			var zero T
+			if !yield(zero, fmt.Errorf("decoding %s item %d on page %d: %w", resultKey, i+1, page, err)) {
+				return false
+			}
+			continue
+		}
+		if !yield(v, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// Collect drains seq into a slice, stopping at the first error.
+func Collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var out []T
+	for v, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// throttle pauses all requests on c for d, e.g. after a 429 response, so
+// parallel page fetches back off together instead of hammering the API.
+func (c *HttpClient) throttle(d time.Duration) {
+	if d <= 0 {
+		d = c.retry.BaseDelay
+	}
+	until := time.Now().Add(d)
+
+	c.throttleMu.Lock()
+	if until.After(c.throttleUntil) {
+		c.throttleUntil = until
+	}
+	c.throttleMu.Unlock()
+}
+
+// waitThrottle blocks until any active throttle window has passed.
+func (c *HttpClient) waitThrottle(ctx context.Context) error {
+	c.throttleMu.Lock()
+	wait := time.Until(c.throttleUntil)
+	c.throttleMu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, wait)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPaginateDecodesTypedRecords(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		fmt.Fprintf(w, `{"time_entries":[{"id":%s1,"duration":60},{"id":%s2,"duration":120}],"meta":{"pages":3}}`, page, page)
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	c := NewHttpClient("test-token")
+	var ids []int
+	for te, err := range Paginate[TimeEntry](context.Background(), c, "/entries", "time_entries", nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, te.ID)
+	}
+	want := []int{11, 12, 21, 22, 31, 32}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+}
+
+func TestPaginateStopsEarly(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, `{"time_entries":[{"id":1},{"id":2}],"meta":{"pages":50}}`)
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	c := NewHttpClient("test-token")
+	c.SetPageConcurrency(2)
+	n := 0
+	for _, err := range Paginate[TimeEntry](context.Background(), c, "/entries", "time_entries", nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	if got := requests.Load(); got > 4 {
+		t.Errorf("expected at most 4 requests after stopping early, got %d", got)
+	}
+}
+
+func TestPaginateSurfacesDecodeErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"time_entries":[{"id":1},{"id":"not-a-number"},{"id":3}],"meta":{"pages":1}}`)
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	c := NewHttpClient("test-token")
+	_, err := ListTimeEntries(c, 1, "2026-02-09", "2026-02-13")
+	if err == nil || !strings.Contains(err.Error(), "time_entries item 2 on page 1") {
+		t.Fatalf("expected decode error for item 2, got %v", err)
+	}
+
+	var ok, failed int
+	for _, err := range Paginate[TimeEntry](context.Background(), c, "/entries", "time_entries", nil) {
+		if err != nil {
+			failed++
+			continue
+		}
+		ok++
+	}
+	if ok != 2 || failed != 1 {
+		t.Errorf("ok=%d failed=%d, want 2 and 1", ok, failed)
+	}
+}
@@ -1,8 +1,9 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"iter"
 )
 
 // Project represents a FreshBooks project.
@@ -13,21 +14,28 @@ type Project struct {
 
 // ListProjects fetches all projects for a given client and returns a map of project ID to title.
 func ListProjects(c *HttpClient, businessID, clientID int) (map[int]string, error) {
-	path := fmt.Sprintf("/projects/business/%d/projects", businessID)
-	raw, err := c.GetPaginated(path, "projects", map[string]string{
-		"client_id": fmt.Sprintf("%d", clientID),
-	})
-	if err != nil {
-		return nil, err
-	}
+	return ListProjectsContext(context.Background(), c, businessID, clientID)
+}
 
-	result := make(map[int]string, len(raw))
-	for _, r := range raw {
-		var p Project
-		if err := json.Unmarshal(r, &p); err != nil {
-			continue
+// ListProjectsContext is like ListProjects but honors ctx.
+func ListProjectsContext(ctx context.Context, c *HttpClient, businessID, clientID int) (map[int]string, error) {
+	result := make(map[int]string)
+	for p, err := range Projects(ctx, c, businessID, clientID) {
+		if err != nil {
+			return nil, err
 		}
 		result[p.ID] = p.Title
 	}
 	return result, nil
 }
+
+// Projects returns an iterator over the projects of a business, limited to
+// one client unless clientID is 0.
+func Projects(ctx context.Context, c *HttpClient, businessID, clientID int) iter.Seq2[Project, error] {
+	path := fmt.Sprintf("/projects/business/%d/projects", businessID)
+	var params map[string]string
+	if clientID != 0 {
+		params = map[string]string{"client_id": fmt.Sprintf("%d", clientID)}
+	}
+	return Paginate[Project](ctx, c, path, "projects", params)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Exchange is a single recorded request/response pair as stored in a fixture file.
+type Exchange struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the redacted request half of an Exchange.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URI    string      `json:"uri"` // path and query, without scheme or host
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the redacted response half of an Exchange.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that forwards requests to Base and writes
+// each redacted exchange to a numbered JSON file in Dir.
+type Recorder struct {
+	Dir  string
+	Base http.RoundTripper
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder creates dir if needed and returns a Recorder that writes into it.
+func NewRecorder(dir string, base http.RoundTripper) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{Dir: dir, Base: base}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := drainBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	ex := Exchange{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URI:    redactURI(req.URL.RequestURI()),
+			Header: redactHeader(req.Header),
+			Body:   string(redactBody(reqBody)),
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: redactHeader(resp.Header),
+			Body:   string(redactBody(respBody)),
+		},
+	}
+	if err := r.write(&ex); err != nil {
+		return nil, fmt.Errorf("failed to record exchange: %w", err)
+	}
+	return resp, nil
+}
+
+var slugRe = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+func (r *Recorder) write(ex *Exchange) error {
+	data, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	r.mu.Lock()
+	r.seq++
+	seq := r.seq
+	r.mu.Unlock()
+
+	path, _, _ := strings.Cut(ex.Request.URI, "?")
+	slug := strings.Trim(slugRe.ReplaceAllString(path, "-"), "-")
+	name := fmt.Sprintf("%04d-%s-%s.json", seq, strings.ToLower(ex.Request.Method), slug)
+	return os.WriteFile(filepath.Join(r.Dir, name), data, 0o644)
+}
+
+// Replayer is an http.RoundTripper that serves responses from fixture files
+// written by a Recorder, without touching the network. Requests are matched
+// by method and URI; repeated requests are answered in recording order.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges map[string][]*Exchange
+}
+
+// NewReplayer loads every fixture file in dir.
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded exchanges found in %s", dir)
+	}
+	sort.Strings(files)
+
+	r := &Replayer{exchanges: make(map[string][]*Exchange)}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var ex Exchange
+		if err := json.Unmarshal(data, &ex); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", filepath.Base(f), err)
+		}
+		key := replayKey(ex.Request.Method, ex.Request.URI)
+		r.exchanges[key] = append(r.exchanges[key], &ex)
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := replayKey(req.Method, req.URL.RequestURI())
+
+	r.mu.Lock()
+	queue := r.exchanges[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for %s", key)
+	}
+	ex := queue[0]
+	// Keep serving the last response once the queue is drained so polling
+	// the same endpoint stays stable.
+	if len(queue) > 1 {
+		r.exchanges[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	header := ex.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", ex.Response.Status, http.StatusText(ex.Response.Status)),
+		StatusCode:    ex.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(ex.Response.Body)),
+		ContentLength: int64(len(ex.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// replayKey normalizes a request into a lookup key with sorted, redacted
+// query parameters, so a live request matches the fixture recorded for it.
+func replayKey(method, uri string) string {
+	path, rawQuery, _ := strings.Cut(redactURI(uri), "?")
+	params := strings.Split(rawQuery, "&")
+	sort.Strings(params)
+	q := strings.Trim(strings.Join(params, "&"), "&")
+	if q == "" {
+		return method + " " + path
+	}
+	return method + " " + path + "?" + q
+}
+
+// drainBody reads *body fully and replaces it with an equivalent reader.
+func drainBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
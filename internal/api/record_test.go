@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/auth/oauth/token" {
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "secret-access", "refresh_token": "secret-refresh"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"entries": []map[string]int{{"id": 7}},
+			"meta":    map[string]int{"pages": 1},
+		})
+	}))
+	defer srv.Close()
+
+	origBase, origTransport := BaseURL, Transport
+	BaseURL = srv.URL
+	defer func() { BaseURL, Transport = origBase, origTransport }()
+
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	Transport = rec
+
+	c := NewHttpClient("secret-bearer")
+	if _, err := c.GetPaginated("/entries", "entries", map[string]string{"client_id": "1"}); err != nil {
+		t.Fatalf("recording GET: %v", err)
+	}
+	if _, _, err := RefreshAccessToken("secret-old-refresh"); err != nil {
+		t.Fatalf("recording token refresh: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 fixture files, got %d", len(files))
+	}
+	for _, f := range files {
+		data, _ := os.ReadFile(f)
+		if strings.Contains(string(data), "secret-") {
+			t.Errorf("%s contains an unredacted secret:\n%s", filepath.Base(f), data)
+		}
+	}
+
+	// Replay against a dead base URL to prove no network is used.
+	BaseURL = "http://127.0.0.1:1"
+	rep, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	Transport = rep
+
+	c = NewHttpClient("other-token")
+	c.SetRetryPolicy(NoRetry)
+	results, err := c.GetPaginated("/entries", "entries", map[string]string{"client_id": "1"})
+	if err != nil {
+		t.Fatalf("replaying GET: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 replayed result, got %d", len(results))
+	}
+
+	err = c.Get("/unknown", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("expected missing fixture error, got %v", err)
+	}
+}
+
+func TestReplayKeyIgnoresParamOrder(t *testing.T) {
+	a := replayKey("GET", "/x?b=2&a=1")
+	b := replayKey("GET", "/x?a=1&b=2")
+	if a != b {
+		t.Errorf("replayKey mismatch: %q vs %q", a, b)
+	}
+}
+
+func TestRecorderRedactsQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	req, _ := http.NewRequest("GET", srv.URL+"/callback?state=s1&code=secret-code&token=secret-token", nil)
+	resp, err := rec.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 fixture file, got %d", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	if strings.Contains(string(data), "secret-") {
+		t.Errorf("fixture contains an unredacted query parameter:\n%s", data)
+	}
+
+	rep, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	req, _ = http.NewRequest("GET", "http://127.0.0.1:1/callback?code=other-code&state=s1&token=other-token", nil)
+	if _, err := rep.RoundTrip(req); err != nil {
+		t.Errorf("replaying a request with different secrets: %v", err)
+	}
+	req, _ = http.NewRequest("GET", "http://127.0.0.1:1/callback?state=s2&code=secret-code", nil)
+	if _, err := rep.RoundTrip(req); err == nil {
+		t.Error("replayed a request whose non-secret parameters differ")
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// secretKeys are JSON object keys whose values must never be written to
+// fixtures or logs.
+var secretKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"client_secret": true,
+	"code":          true,
+	"code_verifier": true,
+	"token":         true,
+	"password":      true,
+}
+
+// secretHeaders are headers whose values must never be written to fixtures or logs.
+var secretHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// redactHeader returns a copy of h with credentials replaced.
+func redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	for _, name := range secretHeaders {
+		if out.Get(name) == "" {
+			continue
+		}
+		if name == "Authorization" {
+			scheme, _, _ := strings.Cut(out.Get(name), " ")
+			out.Set(name, scheme+" "+redacted)
+			continue
+		}
+		out.Set(name, redacted)
+	}
+	return out
+}
+
+// redactQuery returns a copy of q with secret parameters replaced.
+func redactQuery(q url.Values) url.Values {
+	for k := range q {
+		if secretKeys[k] {
+			q.Set(k, redacted)
+		}
+	}
+	return q
+}
+
+// redactURI returns a request URI (path and query) with secret query
+// parameters replaced. The query is re-encoded with its keys sorted.
+func redactURI(uri string) string {
+	path, rawQuery, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+	q, _ := url.ParseQuery(rawQuery)
+	if len(q) == 0 {
+		return path
+	}
+	return path + "?" + redactQuery(q).Encode()
+}
+
+// redactBody replaces the values of secret keys anywhere in a JSON body.
+// Bodies that are not JSON are returned unchanged.
+func redactBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if secretKeys[strings.ToLower(k)] {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactValue(child)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = redactValue(child)
+		}
+	}
+	return v
+}
@@ -1,8 +1,9 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"iter"
 )
 
 // Service represents a FreshBooks service.
@@ -13,19 +14,23 @@ type Service struct {
 
 // ListServices fetches all services for a business and returns a map of service ID to name.
 func ListServices(c *HttpClient, businessID int) (map[int]string, error) {
-	path := fmt.Sprintf("/comments/business/%d/services", businessID)
-	raw, err := c.GetPaginated(path, "services", nil)
-	if err != nil {
-		return nil, err
-	}
+	return ListServicesContext(context.Background(), c, businessID)
+}
 
-	result := make(map[int]string, len(raw))
-	for _, r := range raw {
-		var s Service
-		if err := json.Unmarshal(r, &s); err != nil {
-			continue
+// ListServicesContext is like ListServices but honors ctx.
+func ListServicesContext(ctx context.Context, c *HttpClient, businessID int) (map[int]string, error) {
+	result := make(map[int]string)
+	for svc, err := range Services(ctx, c, businessID) {
+		if err != nil {
+			return nil, err
 		}
-		result[s.ID] = s.Name
+		result[svc.ID] = svc.Name
 	}
 	return result, nil
 }
+
+// Services returns an iterator over the services of a business.
+func Services(ctx context.Context, c *HttpClient, businessID int) iter.Seq2[Service, error] {
+	path := fmt.Sprintf("/comments/business/%d/services", businessID)
+	return Paginate[Service](ctx, c, path, "services", nil)
+}
@@ -1,68 +1,111 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"iter"
+	"strconv"
 )
 
 // TimeEntry represents a FreshBooks time entry.
 type TimeEntry struct {
 	ID             int    `json:"id"`
 	ClientID       int    `json:"client_id"`
+	ProjectID      int    `json:"project_id"`
+	ServiceID      int    `json:"service_id"`
 	Duration       int    `json:"duration"` // seconds
 	StartedAt      string `json:"started_at"`
 	LocalStartedAt string `json:"local_started_at"`
 	Note           string `json:"note"`
 	Billable       bool   `json:"billable"`
+	Billed         bool   `json:"billed"`
+}
+
+// TimeEntryFilter selects time entries using the filters FreshBooks
+// supports. Zero fields do not filter.
+type TimeEntryFilter struct {
+	StartedFrom string // YYYY-MM-DD, inclusive
+	StartedTo   string // YYYY-MM-DD, inclusive
+	ClientID    int
+	ProjectID   int
+	ServiceID   int
+	Billable    *bool
+	Billed      *bool
+}
+
+// params returns f as FreshBooks query parameters.
+func (f TimeEntryFilter) params() map[string]string {
+	params := make(map[string]string)
+	if f.StartedFrom != "" {
+		params["started_from"] = f.StartedFrom + "T00:00:00"
+	}
+	if f.StartedTo != "" {
+		params["started_to"] = f.StartedTo + "T23:59:59"
+	}
+	for key, id := range map[string]int{"client_id": f.ClientID, "project_id": f.ProjectID, "service_id": f.ServiceID} {
+		if id != 0 {
+			params[key] = strconv.Itoa(id)
+		}
+	}
+	for key, b := range map[string]*bool{"billable": f.Billable, "billed": f.Billed} {
+		if b != nil {
+			params[key] = strconv.FormatBool(*b)
+		}
+	}
+	return params
+}
+
+// FilterTimeEntries fetches the time entries matching f.
+func FilterTimeEntries(c *HttpClient, businessID int, f TimeEntryFilter) ([]TimeEntry, error) {
+	return FilterTimeEntriesContext(context.Background(), c, businessID, f)
+}
+
+// FilterTimeEntriesContext is like FilterTimeEntries but honors ctx.
+func FilterTimeEntriesContext(ctx context.Context, c *HttpClient, businessID int, f TimeEntryFilter) ([]TimeEntry, error) {
+	return Collect(TimeEntries(ctx, c, businessID, f.params()))
 }
 
 // ListTimeEntries fetches time entries for a date range.
 func ListTimeEntries(c *HttpClient, businessID int, startedFrom, startedTo string) ([]TimeEntry, error) {
-	path := fmt.Sprintf("/timetracking/business/%d/time_entries", businessID)
-	raw, err := c.GetPaginated(path, "time_entries", map[string]string{
+	return ListTimeEntriesContext(context.Background(), c, businessID, startedFrom, startedTo)
+}
+
+// ListTimeEntriesContext is like ListTimeEntries but honors ctx.
+func ListTimeEntriesContext(ctx context.Context, c *HttpClient, businessID int, startedFrom, startedTo string) ([]TimeEntry, error) {
+	return Collect(TimeEntries(ctx, c, businessID, map[string]string{
 		"started_from": startedFrom + "T00:00:00",
 		"started_to":   startedTo + "T23:59:59",
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	entries := make([]TimeEntry, 0, len(raw))
-	for _, r := range raw {
-		var te TimeEntry
-		if err := json.Unmarshal(r, &te); err != nil {
-			continue
-		}
-		entries = append(entries, te)
-	}
-	return entries, nil
+	}))
 }
 
 // ListUnbilledEntries fetches unbilled, billable time entries for a client.
 func ListUnbilledEntries(c *HttpClient, businessID, clientID int) ([]TimeEntry, error) {
-	path := fmt.Sprintf("/timetracking/business/%d/time_entries", businessID)
-	raw, err := c.GetPaginated(path, "time_entries", map[string]string{
+	return ListUnbilledEntriesContext(context.Background(), c, businessID, clientID)
+}
+
+// ListUnbilledEntriesContext is like ListUnbilledEntries but honors ctx.
+func ListUnbilledEntriesContext(ctx context.Context, c *HttpClient, businessID, clientID int) ([]TimeEntry, error) {
+	return Collect(TimeEntries(ctx, c, businessID, map[string]string{
 		"client_id": fmt.Sprintf("%d", clientID),
 		"billed":    "false",
 		"billable":  "true",
-	})
-	if err != nil {
-		return nil, err
-	}
+	}))
+}
 
-	entries := make([]TimeEntry, 0, len(raw))
-	for _, r := range raw {
-		var te TimeEntry
-		if err := json.Unmarshal(r, &te); err != nil {
-			continue
-		}
-		entries = append(entries, te)
-	}
-	return entries, nil
+// TimeEntries returns an iterator over the time entries of a business
+// matching the given FreshBooks query parameters.
+func TimeEntries(ctx context.Context, c *HttpClient, businessID int, params map[string]string) iter.Seq2[TimeEntry, error] {
+	path := fmt.Sprintf("/timetracking/business/%d/time_entries", businessID)
+	return Paginate[TimeEntry](ctx, c, path, "time_entries", params)
 }
 
 // CreateTimeEntry creates a new time entry via the FreshBooks API.
 func CreateTimeEntry(c *HttpClient, businessID int, entry CreateTimeEntryRequest) (*TimeEntry, error) {
+	return CreateTimeEntryContext(context.Background(), c, businessID, entry)
+}
+
+// CreateTimeEntryContext is like CreateTimeEntry but honors ctx.
+func CreateTimeEntryContext(ctx context.Context, c *HttpClient, businessID int, entry CreateTimeEntryRequest) (*TimeEntry, error) {
 	path := fmt.Sprintf("/timetracking/business/%d/time_entries", businessID)
 	body := map[string]any{
 		"time_entry": map[string]any{
@@ -76,10 +119,13 @@ func CreateTimeEntry(c *HttpClient, businessID int, entry CreateTimeEntryRequest
 			"is_logged":  true,
 		},
 	}
+	if entry.LocalStartedAt != "" {
+		body["time_entry"].(map[string]any)["local_started_at"] = entry.LocalStartedAt
+	}
 	var resp struct {
 		TimeEntry TimeEntry `json:"time_entry"`
 	}
-	if err := c.Post(path, body, &resp); err != nil {
+	if err := c.PostContext(ctx, path, body, &resp); err != nil {
 		return nil, err
 	}
 	return &resp.TimeEntry, nil
@@ -90,25 +136,112 @@ type CreateTimeEntryRequest struct {
 	ClientID  int    `json:"client_id"`
 	ProjectID int    `json:"project_id,omitempty"`
 	ServiceID int    `json:"service_id,omitempty"`
-	Duration  int    `json:"duration"`  // seconds
+	Duration  int    `json:"duration"` // seconds
 	Note      string `json:"note"`
 	Billable  bool   `json:"billable"`
 	StartedAt string `json:"started_at"` // ISO 8601
+	// LocalStartedAt is the start in the user's time zone, without an
+	// offset, so the entry lands on the day the user meant.
+	LocalStartedAt string `json:"local_started_at,omitempty"`
+}
+
+// GetTimeEntry fetches a single time entry.
+func GetTimeEntry(c *HttpClient, businessID, id int) (*TimeEntry, error) {
+	return GetTimeEntryContext(context.Background(), c, businessID, id)
+}
+
+// GetTimeEntryContext is like GetTimeEntry but honors ctx.
+func GetTimeEntryContext(ctx context.Context, c *HttpClient, businessID, id int) (*TimeEntry, error) {
+	path := fmt.Sprintf("/timetracking/business/%d/time_entries/%d", businessID, id)
+	var resp struct {
+		TimeEntry TimeEntry `json:"time_entry"`
+	}
+	if err := c.GetContext(ctx, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.TimeEntry, nil
+}
+
+// TimeEntryUpdate holds the fields to change on a time entry. Nil fields
+// are left as they are.
+type TimeEntryUpdate struct {
+	ClientID  *int
+	ProjectID *int
+	ServiceID *int
+	Duration  *int // seconds
+	Note      *string
+	Billable  *bool
+	Billed    *bool
+	StartedAt *string // ISO 8601
+	// LocalStartedAt is StartedAt in the user's time zone, without an
+	// offset; set it along with StartedAt.
+	LocalStartedAt *string
+}
+
+// UpdateTimeEntry applies u to entry and returns the updated entry.
+// FreshBooks requires the start time and duration on every update, so they
+// are sent from entry unless u changes them, along with entry's local start
+// time unless u moves the entry.
+func UpdateTimeEntry(c *HttpClient, businessID int, entry TimeEntry, u TimeEntryUpdate) (*TimeEntry, error) {
+	return UpdateTimeEntryContext(context.Background(), c, businessID, entry, u)
+}
+
+// UpdateTimeEntryContext is like UpdateTimeEntry but honors ctx.
+func UpdateTimeEntryContext(ctx context.Context, c *HttpClient, businessID int, entry TimeEntry, u TimeEntryUpdate) (*TimeEntry, error) {
+	fields := map[string]any{
+		"started_at": entry.StartedAt,
+		"duration":   entry.Duration,
+		"is_logged":  true,
+	}
+	if u.StartedAt == nil && entry.LocalStartedAt != "" {
+		fields["local_started_at"] = entry.LocalStartedAt
+	}
+	set := func(key string, changed bool, val any) {
+		if changed {
+			fields[key] = val
+		}
+	}
+	set("client_id", u.ClientID != nil, u.ClientID)
+	set("project_id", u.ProjectID != nil, u.ProjectID)
+	set("service_id", u.ServiceID != nil, u.ServiceID)
+	set("duration", u.Duration != nil, u.Duration)
+	set("note", u.Note != nil, u.Note)
+	set("billable", u.Billable != nil, u.Billable)
+	set("billed", u.Billed != nil, u.Billed)
+	set("started_at", u.StartedAt != nil, u.StartedAt)
+	set("local_started_at", u.LocalStartedAt != nil, u.LocalStartedAt)
+
+	path := fmt.Sprintf("/timetracking/business/%d/time_entries/%d", businessID, entry.ID)
+	var resp struct {
+		TimeEntry TimeEntry `json:"time_entry"`
+	}
+	if err := c.PutContext(ctx, path, map[string]any{"time_entry": fields}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.TimeEntry, nil
+}
+
+// DeleteTimeEntry deletes a time entry.
+func DeleteTimeEntry(c *HttpClient, businessID, id int) error {
+	return DeleteTimeEntryContext(context.Background(), c, businessID, id)
+}
+
+// DeleteTimeEntryContext is like DeleteTimeEntry but honors ctx.
+func DeleteTimeEntryContext(ctx context.Context, c *HttpClient, businessID, id int) error {
+	path := fmt.Sprintf("/timetracking/business/%d/time_entries/%d", businessID, id)
+	return c.DeleteContext(ctx, path)
 }
 
 // MarkEntriesAsBilled marks each entry as billed via the API.
 func MarkEntriesAsBilled(c *HttpClient, businessID int, entries []TimeEntry) error {
+	return MarkEntriesAsBilledContext(context.Background(), c, businessID, entries)
+}
+
+// MarkEntriesAsBilledContext is like MarkEntriesAsBilled but honors ctx.
+func MarkEntriesAsBilledContext(ctx context.Context, c *HttpClient, businessID int, entries []TimeEntry) error {
+	billed := true
 	for _, entry := range entries {
-		path := fmt.Sprintf("/timetracking/business/%d/time_entries/%d", businessID, entry.ID)
-		body := map[string]any{
-			"time_entry": map[string]any{
-				"billed":     true,
-				"started_at": entry.StartedAt,
-				"is_logged":  true,
-				"duration":   entry.Duration,
-			},
-		}
-		if err := c.Put(path, body, nil); err != nil {
+		if _, err := UpdateTimeEntryContext(ctx, c, businessID, entry, TimeEntryUpdate{Billed: &billed}); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hev/freshtime/internal/config"
+)
+
+// expiryDelta is how long before expiry a token is refreshed proactively.
+const expiryDelta = time.Minute
+
+// errCannotRefresh is returned by token sources that have no way to obtain a new token.
+var errCannotRefresh = errors.New("token refresh not supported")
+
+// Token is an OAuth access token together with its refresh token and expiry.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time // zero when unknown
+	Scope        string
+	CreatedAt    time.Time
+}
+
+// fresh reports whether the token can be used without refreshing first.
+func (t *Token) fresh() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+// TokenSource supplies bearer tokens to an HttpClient.
+type TokenSource interface {
+	// Token returns an access token, refreshing it first if it is about to expire.
+	Token(ctx context.Context) (string, error)
+	// Refresh is called after the server rejected stale with a 401 and
+	// returns a replacement token.
+	Refresh(ctx context.Context, stale string) (string, error)
+}
+
+type staticTokenSource string
+
+// StaticTokenSource returns a TokenSource that always returns token and never refreshes.
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource(token)
+}
+
+func (s staticTokenSource) Token(context.Context) (string, error) {
+	return string(s), nil
+}
+
+func (s staticTokenSource) Refresh(context.Context, string) (string, error) {
+	return "", errCannotRefresh
+}
+
+// RefreshFunc obtains a new token given the current one.
+type RefreshFunc func(ctx context.Context, current *Token) (*Token, error)
+
+// refreshingTokenSource caches a token and refreshes it on demand. Concurrent
+// refreshes are collapsed into a single call to the refresh function.
+type refreshingTokenSource struct {
+	mu       sync.Mutex
+	tok      *Token
+	refresh  RefreshFunc
+	inflight *refreshCall
+}
+
+type refreshCall struct {
+	done chan struct{}
+	tok  *Token
+	err  error
+}
+
+// NewRefreshingTokenSource returns a concurrency-safe TokenSource starting at
+// tok that calls refresh when the token is near expiry or rejected.
+func NewRefreshingTokenSource(tok *Token, refresh RefreshFunc) TokenSource {
+	if tok == nil {
+		tok = &Token{}
+	}
+	return &refreshingTokenSource{tok: tok, refresh: refresh}
+}
+
+func (s *refreshingTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	tok := s.tok
+	s.mu.Unlock()
+	if tok.fresh() {
+		return tok.AccessToken, nil
+	}
+
+	newTok, err := s.refreshFrom(ctx, tok.AccessToken)
+	if err != nil && tok.AccessToken != "" && ctx.Err() == nil {
+		// Fall back to the current token; the server will reject it with
+		// a 401 if it really has expired.
+		return tok.AccessToken, nil
+	}
+	return newTok, err
+}
+
+func (s *refreshingTokenSource) Refresh(ctx context.Context, stale string) (string, error) {
+	return s.refreshFrom(ctx, stale)
+}
+
+// refreshFrom refreshes the token unless another caller already replaced
+// stale, joining an in-flight refresh if there is one.
+func (s *refreshingTokenSource) refreshFrom(ctx context.Context, stale string) (string, error) {
+	s.mu.Lock()
+	if s.tok.AccessToken != stale && s.tok.fresh() {
+		tok := s.tok
+		s.mu.Unlock()
+		return tok.AccessToken, nil
+	}
+	call := s.inflight
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		s.inflight = call
+		current := *s.tok
+		s.mu.Unlock()
+
+		tok, err := s.refresh(ctx, &current)
+
+		s.mu.Lock()
+		if err == nil {
+			s.tok = tok
+		}
+		call.tok, call.err = tok, err
+		s.inflight = nil
+		close(call.done)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if call.err != nil {
+		return "", call.err
+	}
+	return call.tok.AccessToken, nil
+}
+
+// tokenResponse is the body returned by the OAuth token endpoint.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // seconds
+	Scope        string `json:"scope"`
+	CreatedAt    int64  `json:"created_at"` // unix seconds
+}
+
+// RequestToken posts an OAuth grant to the token endpoint and returns the issued token.
+func RequestToken(ctx context.Context, grant map[string]string) (*Token, error) {
+	payload, err := json.Marshal(grant)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", BaseURL+"/auth/oauth/token", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("token request failed (%d): %s", resp.StatusCode, body)
+	}
+
+	var result tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tok := &Token{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		Scope:        result.Scope,
+		CreatedAt:    now,
+	}
+	if result.CreatedAt > 0 {
+		tok.CreatedAt = time.Unix(result.CreatedAt, 0)
+	}
+	if result.ExpiresIn > 0 {
+		tok.Expiry = tok.CreatedAt.Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+// RefreshTokenContext exchanges a refresh token for a new token.
+func RefreshTokenContext(ctx context.Context, refreshToken string) (*Token, error) {
+	return RequestToken(ctx, map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     os.Getenv("FRESHBOOKS_CLIENT_ID"),
+		"client_secret": os.Getenv("FRESHBOOKS_CLIENT_SECRET"),
+		"refresh_token": refreshToken,
+	})
+}
+
+// RevokeToken revokes an access or refresh token at FreshBooks.
+func RevokeToken(ctx context.Context, token string) error {
+	payload, err := json.Marshal(map[string]string{
+		"client_id":     os.Getenv("FRESHBOOKS_CLIENT_ID"),
+		"client_secret": os.Getenv("FRESHBOOKS_CLIENT_SECRET"),
+		"token":         token,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", BaseURL+"/auth/oauth/revoke", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("token revocation failed (%d): %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// ConfigTokenSource returns a TokenSource backed by cfg. Refreshed tokens are
+// written back to cfg and persisted with config.Save.
+func ConfigTokenSource(cfg *config.Config) TokenSource {
+	tok := &Token{
+		AccessToken:  cfg.AccessToken,
+		RefreshToken: cfg.RefreshToken,
+		Expiry:       cfg.TokenExpiresAt,
+		Scope:        cfg.TokenScope,
+		CreatedAt:    cfg.TokenCreatedAt,
+	}
+	return NewRefreshingTokenSource(tok, func(ctx context.Context, current *Token) (*Token, error) {
+		if current.RefreshToken == "" {
+			return nil, fmt.Errorf("no refresh token available. Run `freshtime setup` to re-authenticate")
+		}
+		tok, err := RefreshTokenContext(ctx, current.RefreshToken)
+		if err != nil {
+			return nil, err
+		}
+		cfg.AccessToken = tok.AccessToken
+		cfg.RefreshToken = tok.RefreshToken
+		cfg.TokenExpiresAt = tok.Expiry
+		cfg.TokenCreatedAt = tok.CreatedAt
+		cfg.TokenScope = tok.Scope
+		if err := config.Save(cfg); err != nil {
+			return nil, fmt.Errorf("failed to save refreshed tokens: %w", err)
+		}
+		return tok, nil
+	})
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentRefreshIsSingleFlight(t *testing.T) {
+	var current atomic.Value
+	current.Store("old-token")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+current.Load().(string) {
+			w.WriteHeader(401)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"ok": "true"})
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	var refreshes atomic.Int32
+	c := NewHttpClient("old-token")
+	c.SetTokenSource(NewRefreshingTokenSource(&Token{AccessToken: "old-token"}, func(ctx context.Context, cur *Token) (*Token, error) {
+		n := refreshes.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		tok := fmt.Sprintf("new-token-%d", n)
+		current.Store(tok)
+		return &Token{AccessToken: tok}, nil
+	}))
+	current.Store("rotated")
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Get("/protected", nil, nil); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := refreshes.Load(); n != 1 {
+		t.Errorf("expected 1 refresh, got %d", n)
+	}
+}
+
+func TestRefreshHappensMoreThanOnce(t *testing.T) {
+	var current atomic.Value
+	current.Store("token-0")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+current.Load().(string) {
+			w.WriteHeader(401)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	n := 0
+	c := NewHttpClient("token-0")
+	c.SetRefreshFunc(func() (string, error) {
+		n++
+		tok := fmt.Sprintf("token-%d", n)
+		current.Store(tok)
+		return tok, nil
+	})
+
+	for i := 1; i <= 3; i++ {
+		current.Store(fmt.Sprintf("server-rotated-%d", i))
+		if err := c.Get("/protected", nil, nil); err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+	}
+	if n != 3 {
+		t.Errorf("expected 3 refreshes, got %d", n)
+	}
+}
+
+func TestProactiveRefreshBeforeExpiry(t *testing.T) {
+	refreshed := false
+	ts := NewRefreshingTokenSource(&Token{
+		AccessToken: "old",
+		Expiry:      time.Now().Add(10 * time.Second),
+	}, func(ctx context.Context, cur *Token) (*Token, error) {
+		refreshed = true
+		return &Token{AccessToken: "new", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	tok, err := ts.Token(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !refreshed || tok != "new" {
+		t.Errorf("expected proactive refresh, got token %q", tok)
+	}
+}
+
+func TestRequestTokenRecordsExpiry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "a",
+			"refresh_token": "r",
+			"expires_in":    3600,
+			"created_at":    1770000000,
+		})
+	}))
+	defer srv.Close()
+
+	origBase := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = origBase }()
+
+	tok, err := RefreshTokenContext(context.Background(), "r")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := time.Unix(1770000000+3600, 0)
+	if !tok.Expiry.Equal(want) {
+		t.Errorf("expiry = %v, want %v", tok.Expiry, want)
+	}
+}
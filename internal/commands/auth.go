@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/config"
+)
+
+// AuthCmd returns the auth command for managing stored credentials.
+func AuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage FreshBooks credentials",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show who the stored token belongs to and when it expires",
+		Long: `Show the FreshBooks user and business for the current profile, the age,
+expiry and scopes of its token. Exits non-zero if the token is missing or
+rejected, so scripts can check it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthStatus(cmd.Context())
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "refresh",
+		Short: "Exchange the refresh token for a new access token now",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthRefresh(cmd.Context())
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "logout",
+		Short: "Revoke the tokens at FreshBooks and remove them from this machine",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthLogout(cmd.Context())
+		},
+	})
+	cmd.AddCommand(authMigrateCmd())
+	return cmd
+}
+
+// loadLoggedIn loads the active profile and fails if it holds no tokens.
+func loadLoggedIn() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AccessToken == "" && cfg.RefreshToken == "" {
+		return nil, fmt.Errorf("not logged in (profile %q). Run `freshtime setup` to authenticate", cfg.Name)
+	}
+	return cfg, nil
+}
+
+func runAuthStatus(ctx context.Context) error {
+	cfg, err := loadLoggedIn()
+	if err != nil {
+		return err
+	}
+
+	user, err := api.GetCurrentUserContext(ctx, api.NewClient(cfg))
+	if err != nil {
+		return fmt.Errorf("token for profile %q is not valid: %w", cfg.Name, err)
+	}
+
+	fmt.Printf("Profile:   %s\n", cfg.Name)
+	fmt.Printf("User:      %s %s <%s>\n", user.FirstName, user.LastName, user.Email)
+
+	var business *api.Business
+	for i, b := range user.Businesses {
+		if b.ID == cfg.BusinessID {
+			business = &user.Businesses[i]
+		}
+	}
+	if business == nil {
+		fmt.Printf("Business:  %d (not accessible)\n", cfg.BusinessID)
+	} else {
+		fmt.Printf("Business:  %s (%d, account %s)\n", business.Name, business.ID, business.AccountID)
+	}
+	fmt.Printf("Token:     %s\n", describeToken(cfg, time.Now()))
+	scope := cfg.TokenScope
+	if scope == "" {
+		scope = "unknown"
+	}
+	fmt.Printf("Scopes:    %s\n", scope)
+
+	if business == nil {
+		return fmt.Errorf("this token has no access to business %d. Run `freshtime setup` to pick another", cfg.BusinessID)
+	}
+	return nil
+}
+
+// describeToken summarizes the age and expiry of the token in cfg.
+func describeToken(cfg *config.Config, now time.Time) string {
+	age := "issued at an unknown time"
+	if !cfg.TokenCreatedAt.IsZero() {
+		age = fmt.Sprintf("issued %s ago", formatElapsed(now.Sub(cfg.TokenCreatedAt)))
+	}
+
+	expiry := "expiry unknown"
+	switch exp := cfg.TokenExpiresAt; {
+	case exp.IsZero():
+	case exp.After(now):
+		expiry = fmt.Sprintf("expires in %s (%s)", formatElapsed(exp.Sub(now)), exp.Local().Format("2006-01-02 15:04"))
+	case cfg.RefreshToken != "":
+		expiry = fmt.Sprintf("expired %s ago, will be refreshed on next use", formatElapsed(now.Sub(exp)))
+	default:
+		expiry = fmt.Sprintf("expired %s ago", formatElapsed(now.Sub(exp)))
+	}
+	return "valid, " + age + ", " + expiry
+}
+
+func runAuthRefresh(ctx context.Context) error {
+	cfg, err := loadLoggedIn()
+	if err != nil {
+		return err
+	}
+	if cfg.RefreshToken == "" {
+		return fmt.Errorf("profile %q has no refresh token. Run `freshtime setup` to re-authenticate", cfg.Name)
+	}
+
+	if _, err := api.ConfigTokenSource(cfg).Refresh(ctx, cfg.AccessToken); err != nil {
+		return fmt.Errorf("refresh failed: %w", err)
+	}
+	fmt.Printf("Refreshed token for profile %q.\n", cfg.Name)
+	if !cfg.TokenExpiresAt.IsZero() {
+		fmt.Printf("New token expires %s.\n", cfg.TokenExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func runAuthLogout(ctx context.Context) error {
+	cfg, err := loadLoggedIn()
+	if err != nil {
+		return err
+	}
+	// Tokens from the environment are never saved, so logging out would
+	// leave the stored ones in place.
+	for _, key := range []string{"access_token", "refresh_token"} {
+		if src := cfg.Source(key); src != "" {
+			return fmt.Errorf("%s is set by %s; unset it to log out of profile %q", key, src, cfg.Name)
+		}
+	}
+
+	var revokeErrs []error
+	for _, token := range []string{cfg.RefreshToken, cfg.AccessToken} {
+		if token == "" {
+			continue
+		}
+		if err := api.RevokeToken(ctx, token); err != nil {
+			revokeErrs = append(revokeErrs, err)
+		}
+	}
+
+	cfg.AccessToken = ""
+	cfg.RefreshToken = ""
+	cfg.TokenExpiresAt = time.Time{}
+	cfg.TokenCreatedAt = time.Time{}
+	cfg.TokenScope = ""
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to remove tokens: %w", err)
+	}
+
+	fmt.Printf("Logged out of profile %q.\n", cfg.Name)
+	if err := errors.Join(revokeErrs...); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: tokens were removed locally but could not be revoked at FreshBooks: %v\n", err)
+	}
+	return nil
+}
+
+func authMigrateCmd() *cobra.Command {
+	var (
+		to      string
+		keyFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move stored tokens to another credential store",
+		Long: `Move the tokens of every profile to another credential store.
+
+Stores:
+  inline     in config.json, as older versions of freshtime did
+  file       in credentials.json next to the config, readable only by you
+  encrypted  in credentials.enc, encrypted with AES-256-GCM using a key
+             derived from $` + config.PassphraseEnv + ` or read from --key-file`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAuthMigrate(to, keyFile)
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", config.StoreFile, "Credential store to move tokens to: inline, file or encrypted")
+	cmd.Flags().StringVar(&keyFile, "key-file", "", "Key file for the encrypted store (at least 32 random bytes) instead of a passphrase")
+
+	return cmd
+}
+
+func runAuthMigrate(to, keyFile string) error {
+	if keyFile != "" {
+		if to != config.StoreEncrypted {
+			return fmt.Errorf("--key-file only applies to --to %s", config.StoreEncrypted)
+		}
+		abs, err := filepath.Abs(keyFile)
+		if err != nil {
+			return err
+		}
+		keyFile = abs
+	}
+
+	n, err := config.MigrateCredentials(to, keyFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Moved tokens for %d profile(s) to the %s credential store.\n", n, to)
+	return nil
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hev/freshtime/internal/config"
+)
+
+func TestAuthStatus(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+
+	out, err := captureStdout(t, func() error { return runAuthStatus(ctx) })
+	if err != nil {
+		t.Fatalf("runAuthStatus: %v", err)
+	}
+	for _, want := range []string{"Profile:   default", "demo@example.com", fake.BusinessName} {
+		if !strings.Contains(out, want) {
+			t.Errorf("status output missing %q:\n%s", want, out)
+		}
+	}
+
+	// A rejected token makes status fail.
+	fake.Token = "some-other-token"
+	if _, err := captureStdout(t, func() error { return runAuthStatus(ctx) }); err == nil {
+		t.Error("expected error for rejected token")
+	}
+}
+
+func TestAuthRefreshAndLogout(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.RefreshToken = "test-refresh"
+	if err := config.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error { return runAuthRefresh(ctx) }); err != nil {
+		t.Fatalf("runAuthRefresh: %v", err)
+	}
+	cfg, err = config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(cfg.AccessToken, "fake-access-") || cfg.TokenExpiresAt.IsZero() || cfg.TokenScope == "" {
+		t.Errorf("refreshed config = %+v", cfg)
+	}
+	access, refresh := cfg.AccessToken, cfg.RefreshToken
+
+	if _, err := captureStdout(t, func() error { return runAuthLogout(ctx) }); err != nil {
+		t.Fatalf("runAuthLogout: %v", err)
+	}
+	if !fake.Revoked(access) || !fake.Revoked(refresh) {
+		t.Error("tokens were not revoked at FreshBooks")
+	}
+	cfg, err = config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AccessToken != "" || cfg.RefreshToken != "" {
+		t.Errorf("tokens left after logout: %+v", cfg)
+	}
+	if cfg.BusinessID != fake.BusinessID {
+		t.Errorf("logout dropped profile settings: %+v", cfg)
+	}
+
+	if _, err := captureStdout(t, func() error { return runAuthStatus(ctx) }); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("expected not logged in error, got %v", err)
+	}
+}
+
+func TestAuthLogoutRefusesEnvToken(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+	t.Setenv("FRESHTIME_ACCESS_TOKEN", "env-token")
+
+	_, err := captureStdout(t, func() error { return runAuthLogout(ctx) })
+	if err == nil || !strings.Contains(err.Error(), "FRESHTIME_ACCESS_TOKEN") {
+		t.Fatalf("expected an error naming FRESHTIME_ACCESS_TOKEN, got %v", err)
+	}
+	if fake.Revoked("env-token") || fake.Revoked("test-token") {
+		t.Error("tokens were revoked although logout was refused")
+	}
+
+	t.Setenv("FRESHTIME_ACCESS_TOKEN", "")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AccessToken != "test-token" {
+		t.Errorf("stored access token = %q, want it kept", cfg.AccessToken)
+	}
+}
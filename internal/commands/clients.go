@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -17,19 +18,19 @@ func ClientsCmd() *cobra.Command {
 		Use:   "clients",
 		Short: "List clients with their IDs",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runClients()
+			return runClients(cmd.Context())
 		},
 	}
 }
 
-func runClients() error {
+func runClients(ctx context.Context) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return err
 	}
 
 	http := api.NewClient(cfg)
-	clients, err := api.ListClients(http, cfg.AccountID)
+	clients, err := api.ListClientsContext(ctx, http, cfg.AccountID)
 	if err != nil {
 		return err
 	}
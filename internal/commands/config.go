@@ -0,0 +1,253 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hev/freshtime/internal/config"
+)
+
+// ConfigCmd returns the config command for reading and changing settings.
+func ConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Get and set freshtime settings",
+		Long: `Get and set freshtime settings.
+
+Keys belong to the current profile (see --profile) unless they start with
+"oauth.", which are shared by all profiles. Set a client's hourly rate with
+client_rates.<client-id>, e.g.
+
+  freshtime config set client_rates.12345 150
+  freshtime config set default_currency EUR
+
+Logged and invoiced time is rounded by the rounding.* keys, or for one
+client by client_rounding.<client-id>.*; rounding in a .freshtime.json for
+that client wins over both, e.g.
+
+  freshtime config set rounding.increment 15m
+  freshtime config set client_rounding.12345.increment 6m
+  freshtime config set client_rounding.12345.mode up
+
+Tokens are masked unless --show-secrets is given.
+
+Flags and environment variables override the stored settings; see
+"freshtime config resolve".`,
+	}
+
+	var showSecrets, all bool
+	get := &cobra.Command{
+		Use:   "get KEY",
+		Short: "Print the value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigGet(args[0], showSecrets)
+		},
+	}
+	get.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print tokens instead of masking them")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "Print all settings that have a value",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigList(showSecrets, all)
+		},
+	}
+	list.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print tokens instead of masking them")
+	list.Flags().BoolVar(&all, "all", false, "Include settings that are not set, with a description")
+
+	resolve := &cobra.Command{
+		Use:   "resolve",
+		Short: "Print the effective configuration and where each value came from",
+		Long: `Print the effective configuration and where each value came from.
+
+Values are resolved in this order, first match wins:
+
+  1. flags (--profile, --config, --account-id, --business-id)
+  2. environment (FRESHTIME_PROFILE, FRESHTIME_CONFIG, FRESHTIME_ACCESS_TOKEN,
+     FRESHTIME_REFRESH_TOKEN, FRESHTIME_ACCOUNT_ID, FRESHTIME_BUSINESS_ID,
+     FRESHTIME_CURRENCY, FRESHTIME_CLIENT_ID, FRESHTIME_PROJECT_ID,
+     FRESHTIME_SERVICE_ID)
+  3. project files (.freshtime.json in the current directory and each parent
+     up to the git repository root; a nearer file overrides the keys it sets)
+  4. the user config file`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigResolve(showSecrets)
+		},
+	}
+	resolve.Flags().BoolVar(&showSecrets, "show-secrets", false, "Print tokens instead of masking them")
+
+	cmd.AddCommand(get, list, resolve)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set KEY VALUE",
+		Short: "Validate and store a setting",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.Set(args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("Set %s.\n", args[0])
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "unset KEY",
+		Short: "Remove a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.Unset(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Unset %s.\n", args[0])
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in $EDITOR and validate it on save",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigEdit()
+		},
+	})
+	return cmd
+}
+
+func runConfigGet(key string, showSecrets bool) error {
+	s, err := config.LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	value, err := config.Get(key)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("%s is not set", key)
+	}
+	if s.Secret && !showSecrets {
+		value = config.MaskSecret(value)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigList(showSecrets, all bool) error {
+	entries, err := config.List(all)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		value := e.Value
+		if e.Secret && !showSecrets {
+			value = config.MaskSecret(value)
+		}
+		if all {
+			s, _ := config.LookupSetting(e.Key)
+			fmt.Printf("%-24s = %-20s # %s\n", e.Key, value, s.Help)
+			continue
+		}
+		fmt.Printf("%s = %s\n", e.Key, value)
+	}
+	return nil
+}
+
+func runConfigResolve(showSecrets bool) error {
+	values, err := config.Resolve()
+	if err != nil {
+		return err
+	}
+	for _, r := range values {
+		value := r.Value
+		if r.Secret && !showSecrets {
+			value = config.MaskSecret(value)
+		}
+		fmt.Printf("%-24s = %-28s # %s\n", r.Key, value, r.Source)
+	}
+	return nil
+}
+
+// editorCommand returns the user's editor command line.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// runConfigEdit opens a copy of the config file in the user's editor and
+// replaces the real file only if the edited copy is valid.
+func runConfigEdit() error {
+	path := config.Path()
+	original, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("config not found. Run `freshtime setup` to configure your token")
+	}
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "config-edit-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(original)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for {
+		editor := editorCommand()
+		c := exec.Command(editor[0], append(editor[1:], tmp.Name())...)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("editor %s failed: %w", editor[0], err)
+		}
+
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes.")
+			return nil
+		}
+
+		f, err := config.ParseFile(edited)
+		if err == nil {
+			err = f.Validate()
+		}
+		if err == nil {
+			if err := f.Save(); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			fmt.Printf("Saved %s.\n", path)
+			return nil
+		}
+
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		fmt.Print("Edit again? [Y/n] ")
+		answer, _ := in.ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a == "n" || a == "no" {
+			return fmt.Errorf("changes discarded")
+		}
+	}
+}
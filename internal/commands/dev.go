@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/fakefb"
+)
+
+// DevCmd returns the dev command with developer tooling subcommands.
+func DevCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dev",
+		Short: "Developer tools",
+	}
+	cmd.AddCommand(fakeServerCmd())
+	return cmd
+}
+
+func fakeServerCmd() *cobra.Command {
+	var (
+		addr       string
+		empty      bool
+		initConfig bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fake-server",
+		Short: "Run an in-memory fake FreshBooks API for demos and testing",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFakeServer(cmd.Context(), addr, empty, initConfig)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8458", "Address to listen on")
+	cmd.Flags().BoolVar(&empty, "empty", false, "Start with no sample data")
+	cmd.Flags().BoolVar(&initConfig, "init-config", false, "Write a config pointing at the fake account if none exists")
+
+	return cmd
+}
+
+func runFakeServer(ctx context.Context, addr string, empty, initConfig bool) error {
+	fake := fakefb.NewDemo()
+	if empty {
+		fake = fakefb.New()
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{Handler: fake}
+
+	if initConfig {
+		if existing, err := config.Load(); err == nil {
+			ln.Close()
+			return fmt.Errorf("refusing to overwrite existing profile %q in %s (pick another with --profile)", existing.Name, config.Path())
+		}
+		cfg := &config.Config{
+			AccessToken: "fake-token",
+			AccountID:   fake.AccountID,
+			BusinessID:  fake.BusinessID,
+		}
+		if err := config.Save(cfg); err != nil {
+			ln.Close()
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("Wrote profile %q to %s\n", cfg.Name, config.Path())
+	}
+
+	url := "http://" + ln.Addr().String()
+	fmt.Printf("Fake FreshBooks API listening on %s\n\n", url)
+	fmt.Println("Point freshtime at it with:")
+	fmt.Printf("  export %s=%s\n", apiURLEnv, url)
+	if !initConfig {
+		fmt.Printf("  export FRESHTIME_ACCESS_TOKEN=fake-token FRESHTIME_ACCOUNT_ID=%s FRESHTIME_BUSINESS_ID=%d\n", fake.AccountID, fake.BusinessID)
+	}
+	fmt.Println()
+	fmt.Printf("  Account:  %s\n", fake.AccountID)
+	fmt.Printf("  Business: %d\n", fake.BusinessID)
+	fmt.Println("Any bearer token is accepted. Press Ctrl-C to stop.")
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		server.Close()
+		return nil
+	}
+}
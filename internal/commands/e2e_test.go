@@ -0,0 +1,281 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/fakefb"
+	"github.com/hev/freshtime/internal/format"
+	"github.com/hev/freshtime/internal/testutil"
+)
+
+// useFake starts a fake FreshBooks server and writes a config that targets it.
+func useFake(t *testing.T) *fakefb.Server {
+	t.Helper()
+	fake := fakefb.New()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	origBase := api.BaseURL
+	api.BaseURL = srv.URL
+	t.Cleanup(func() { api.BaseURL = origBase })
+
+	testutil.SetHome(t)
+	t.Chdir(t.TempDir())
+	cfg := &config.Config{AccessToken: "test-token", AccountID: fake.AccountID, BusinessID: fake.BusinessID}
+	if err := config.Save(cfg); err != nil {
+		t.Fatalf("saving config: %v", err)
+	}
+	return fake
+}
+
+func TestLogWeeklyInvoiceWorkflow(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, Duration: 7200, StartedAt: "2026-02-10T15:00:00Z", Note: "Earlier work", Billable: true})
+
+	if _, err := captureStdout(t, func() error {
+		return runLog(ctx, "Pairing session", logWhen{duration: "1h30m"}, false, &entryOptions{clientID: acme})
+	}); err != nil {
+		t.Fatalf("runLog: %v", err)
+	}
+	if n := len(fake.TimeEntries()); n != 2 {
+		t.Fatalf("expected 2 entries after log, got %d", n)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runWeekly(ctx, "2026-02-10", true)
+	})
+	if err != nil {
+		t.Fatalf("runWeekly: %v", err)
+	}
+	var summary format.WeeklySummary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("invalid weekly JSON: %v\n%s", err, out)
+	}
+	if c := findClient(summary.Clients, "Acme Corp"); c == nil || c.Daily[1] != 2 {
+		t.Errorf("Acme Corp Tuesday = %+v, want 2h", c)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return runInvoice(ctx, acme, "100", "USD", false, false, "")
+	})
+	if err != nil {
+		t.Fatalf("runInvoice: %v", err)
+	}
+	if !strings.Contains(out, "2 entries marked as billed") {
+		t.Errorf("expected entries to be billed, got:\n%s", out)
+	}
+	invoices := fake.Invoices()
+	if len(invoices) != 1 || invoices[0].Amount.Amount != "350.00" {
+		t.Fatalf("unexpected invoices: %+v", invoices)
+	}
+
+	out, err = captureStdout(t, func() error {
+		return runInvoice(ctx, acme, "100", "USD", false, false, "")
+	})
+	if err != nil {
+		t.Fatalf("second runInvoice: %v", err)
+	}
+	if !strings.Contains(out, "No unbilled time entries") {
+		t.Errorf("expected nothing left to invoice, got:\n%s", out)
+	}
+}
+
+func TestLogAndInvoiceUseProjectSettings(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	notBillable := false
+	pc := &config.ProjectConfig{
+		ClientID:     acme,
+		Billable:     &notBillable,
+		Rate:         "120",
+		Currency:     "EUR",
+		NoteTemplate: "[{{.Message}}] {{.Tags}}",
+		Tags:         []string{"ops"},
+		Rounding:     &config.Rounding{Increment: "15m", Mode: "up"},
+	}
+	if err := config.SaveProjectConfig(".", pc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runLog(ctx, "Deploy", logWhen{duration: "50m"}, false, &entryOptions{})
+	}); err != nil {
+		t.Fatalf("runLog: %v", err)
+	}
+	// Flags win over the project file.
+	if _, err := captureStdout(t, func() error {
+		return runLog(ctx, "Hotfix", logWhen{duration: "50m"}, true, &entryOptions{billable: true})
+	}); err != nil {
+		t.Fatalf("runLog: %v", err)
+	}
+
+	entries := fake.TimeEntries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Duration != 3600 || e.Note != "[Deploy] #ops" || e.Billable {
+		t.Errorf("first entry = %+v, want 1h rounded up, templated note, not billable", e)
+	}
+	if e := entries[1]; e.Duration != 3000 || !e.Billable {
+		t.Errorf("second entry = %+v, want 50m, billable", e)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runInvoice(ctx, acme, "", "", true, false, "")
+	})
+	if err != nil {
+		t.Fatalf("runInvoice: %v", err)
+	}
+	if !strings.Contains(out, "120 EUR/hr") {
+		t.Errorf("expected the project's rate and currency, got:\n%s", out)
+	}
+}
+
+func TestClientRoundingAppliesToLogAndInvoice(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	// Entered in the web UI, so never rounded.
+	fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, Duration: 20 * 60, StartedAt: "2026-02-10T15:00:00Z", Note: "Call", Billable: true})
+	for key, value := range map[string]string{
+		"rounding.increment":                              "15m",
+		fmt.Sprintf("client_rounding.%d.increment", acme): "6m",
+		fmt.Sprintf("client_rounding.%d.mode", acme):      "up",
+		fmt.Sprintf("client_rounding.%d.minimum", acme):   "15m",
+	} {
+		if err := config.Set(key, value); err != nil {
+			t.Fatalf("config.Set(%s): %v", key, err)
+		}
+	}
+
+	for _, d := range []string{"5m", "31m"} {
+		if _, err := captureStdout(t, func() error {
+			return runLog(ctx, "Email", logWhen{duration: d}, false, &entryOptions{clientID: acme})
+		}); err != nil {
+			t.Fatalf("runLog: %v", err)
+		}
+	}
+	entries := fake.TimeEntries()
+	if got := []int{entries[1].Duration, entries[2].Duration}; got[0] != 18*60 || got[1] != 36*60 {
+		t.Errorf("logged durations = %v, want 18m (15m minimum, 6m up) and 36m (6m up)", got)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runInvoice(ctx, acme, "100", "USD", true, false, "")
+	})
+	if err != nil {
+		t.Fatalf("runInvoice: %v", err)
+	}
+	for _, want := range []string{
+		"Hours:   1.30 (logged 1.23h, +0.07h)",
+		fmt.Sprintf("Rounding: 6m up, minimum 15m (client_rounding.%d)", acme),
+		"Total:   130.00 USD",
+		"2026-02-10  0.40h  Call (logged 0.33h, +0.07h)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = captureStdout(t, func() error {
+		return runInvoice(ctx, acme, "100", "USD", true, true, "")
+	})
+	if err != nil {
+		t.Fatalf("runInvoice: %v", err)
+	}
+	if !strings.Contains(out, "Hours:   1.23\n") || strings.Contains(out, "Rounding:") {
+		t.Errorf("--no-round should invoice durations as logged:\n%s", out)
+	}
+}
+
+func TestProjectRoundingAppliesToLogAndInvoice(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, Duration: 20 * 60, StartedAt: "2026-02-10T15:00:00Z", Note: "Call", Billable: true})
+	for key, value := range map[string]string{
+		fmt.Sprintf("client_rates.%d", acme):              "100",
+		fmt.Sprintf("client_rounding.%d.increment", acme): "6m",
+		fmt.Sprintf("client_rounding.%d.mode", acme):      "up",
+	} {
+		if err := config.Set(key, value); err != nil {
+			t.Fatalf("config.Set(%s): %v", key, err)
+		}
+	}
+
+	// The project's rule for its client wins over the config's, when
+	// logging and when invoicing from the project.
+	project, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SaveProjectConfig(project, &config.ProjectConfig{
+		ClientID: acme,
+		Rounding: &config.Rounding{Increment: "15m", Mode: "up"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := captureStdout(t, func() error {
+		return runLog(ctx, "Email", logWhen{duration: "5m"}, false, &entryOptions{})
+	}); err != nil {
+		t.Fatalf("runLog: %v", err)
+	}
+	if d := fake.TimeEntries()[1].Duration; d != 15*60 {
+		t.Errorf("logged %ds, want 15m from the project's rule", d)
+	}
+
+	dryRun := func() string {
+		t.Helper()
+		out, err := captureStdout(t, func() error {
+			return runInvoice(ctx, acme, "", "", true, false, "")
+		})
+		if err != nil {
+			t.Fatalf("runInvoice: %v", err)
+		}
+		return out
+	}
+	out := dryRun()
+	for _, want := range []string{
+		"Hours:   0.75 (logged 0.58h, +0.17h)",
+		"Rounding: 15m up (project file " + filepath.Join(project, config.ProjectConfigFile) + ")",
+		"Total:   75.00 USD",
+		"2026-02-10  0.50h  Call (logged 0.33h, +0.17h)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run in the project missing %q:\n%s", want, out)
+		}
+	}
+
+	// A project file for no client in particular changes neither the rate
+	// nor the rounding of the invoice.
+	other := t.TempDir()
+	if err := config.SaveProjectConfig(other, &config.ProjectConfig{
+		Rate:     "999",
+		Rounding: &config.Rounding{Increment: "1h", Mode: "up"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(other)
+	out = dryRun()
+	for _, want := range []string{
+		"Hours:   0.70 (logged 0.58h, +0.12h)",
+		fmt.Sprintf("Rounding: 6m up (client_rounding.%d)", acme),
+		"Total:   70.00 USD",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run elsewhere missing %q:\n%s", want, out)
+		}
+	}
+}
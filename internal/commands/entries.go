@@ -0,0 +1,423 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/duration"
+	"github.com/hev/freshtime/internal/format"
+)
+
+// EntriesCmd returns the entries command for working with individual time
+// entries.
+func EntriesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "entries",
+		Short: "List and manage individual time entries",
+	}
+	cmd.AddCommand(entriesListCmd(), entriesEditCmd(), entriesDeleteCmd())
+	return cmd
+}
+
+// entryQuery selects time entries. The api filter runs server-side; note
+// and match are applied to the results.
+type entryQuery struct {
+	filter api.TimeEntryFilter
+	note   string
+	match  *regexp.Regexp
+}
+
+// matches reports whether e passes the client-side filters.
+func (q *entryQuery) matches(e api.TimeEntry) bool {
+	if q.note != "" && !strings.Contains(strings.ToLower(e.Note), strings.ToLower(q.note)) {
+		return false
+	}
+	if q.match != nil && !q.match.MatchString(e.Note) {
+		return false
+	}
+	return true
+}
+
+func entriesListCmd() *cobra.Command {
+	var (
+		from, to         string
+		client           int
+		project          int
+		service          int
+		billable, billed bool
+		note, match      string
+		jsonOutput       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List time entries",
+		Long: `List time entries, newest first.
+
+Without --from and --to, entries from the last 7 days are shown. Date,
+client, project, service, --billable and --billed filters are applied by
+FreshBooks; --note and --match are applied locally.`,
+		Example: `  freshtime entries list --from 2026-01-01 --to 2026-01-31 --client 12345
+  freshtime entries list --billable --billed=false
+  freshtime entries list --match '(?i)^review' --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			q := &entryQuery{
+				filter: api.TimeEntryFilter{ClientID: client, ProjectID: project, ServiceID: service},
+				note:   note,
+			}
+			var err error
+			q.filter.StartedFrom, q.filter.StartedTo, err = entryDateRange(from, to, time.Now())
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("billable") {
+				q.filter.Billable = &billable
+			}
+			if cmd.Flags().Changed("billed") {
+				q.filter.Billed = &billed
+			}
+			if match != "" {
+				if q.match, err = regexp.Compile(match); err != nil {
+					return fmt.Errorf("invalid --match: %w", err)
+				}
+			}
+			return runEntriesList(cmd.Context(), q, jsonOutput)
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "Only entries started on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "Only entries started on or before this date (YYYY-MM-DD, default today)")
+	cmd.Flags().IntVar(&client, "client", 0, "Only entries for this client ID")
+	cmd.Flags().IntVar(&project, "project", 0, "Only entries for this project ID")
+	cmd.Flags().IntVar(&service, "service", 0, "Only entries for this service ID")
+	cmd.Flags().BoolVar(&billable, "billable", false, "Only billable entries (--billable=false for non-billable)")
+	cmd.Flags().BoolVar(&billed, "billed", false, "Only billed entries (--billed=false for unbilled)")
+	cmd.Flags().StringVar(&note, "note", "", "Only entries whose note contains this text (case-insensitive)")
+	cmd.Flags().StringVar(&match, "match", "", "Only entries whose note matches this regular expression")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+// entryDateRange validates the --from and --to dates and fills in the
+// defaults: today for to, and 7 days up to to for from.
+func entryDateRange(from, to string, now time.Time) (string, string, error) {
+	end := now
+	if to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid --to date %q (expected YYYY-MM-DD)", to)
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -6)
+	if from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid --from date %q (expected YYYY-MM-DD)", from)
+		}
+		start = t
+	}
+	if start.Format("2006-01-02") > end.Format("2006-01-02") {
+		return "", "", fmt.Errorf("--from %s is after --to %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+	return start.Format("2006-01-02"), end.Format("2006-01-02"), nil
+}
+
+func runEntriesList(ctx context.Context, q *entryQuery, jsonOutput bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	http := api.NewClient(cfg)
+	all, err := api.FilterTimeEntriesContext(ctx, http, cfg.BusinessID, q.filter)
+	if err != nil {
+		return err
+	}
+	var entries []api.TimeEntry
+	for _, e := range all {
+		if q.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	rows, err := entryRows(ctx, http, cfg, entries)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		fmt.Println(format.EntriesJSON(rows))
+	} else {
+		fmt.Println(format.EntriesTable(rows))
+	}
+	return nil
+}
+
+// entryRows resolves the client, project and service names of entries,
+// newest first. Projects and services are only fetched when an entry
+// refers to one.
+func entryRows(ctx context.Context, http *api.HttpClient, cfg *config.Config, entries []api.TimeEntry) ([]format.EntryRow, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	clients, err := api.ListClientsContext(ctx, http, cfg.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+	var projects, services map[int]string
+	for _, e := range entries {
+		if e.ProjectID != 0 && projects == nil {
+			if projects, err = api.ListProjectsContext(ctx, http, cfg.BusinessID, 0); err != nil {
+				return nil, fmt.Errorf("failed to list projects: %w", err)
+			}
+		}
+		if e.ServiceID != 0 && services == nil {
+			if services, err = api.ListServicesContext(ctx, http, cfg.BusinessID); err != nil {
+				return nil, fmt.Errorf("failed to list services: %w", err)
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entryStart(entries[i]) > entryStart(entries[j])
+	})
+	rows := make([]format.EntryRow, len(entries))
+	for i, e := range entries {
+		client := clients[e.ClientID]
+		if client == "" {
+			client = fmt.Sprintf("Client #%d", e.ClientID)
+		}
+		rows[i] = format.EntryRow{
+			ID:        e.ID,
+			Date:      splitDateTime(entryStart(e)),
+			Duration:  e.Duration,
+			Hours:     math.Round(float64(e.Duration)/3600*100) / 100,
+			ClientID:  e.ClientID,
+			Client:    client,
+			ProjectID: e.ProjectID,
+			Project:   projects[e.ProjectID],
+			ServiceID: e.ServiceID,
+			Service:   services[e.ServiceID],
+			Billable:  e.Billable,
+			Billed:    e.Billed,
+			Note:      e.Note,
+		}
+	}
+	return rows, nil
+}
+
+// entryStart returns when e started, in local time if FreshBooks knows it.
+func entryStart(e api.TimeEntry) string {
+	if e.LocalStartedAt != "" {
+		return e.LocalStartedAt
+	}
+	return e.StartedAt
+}
+
+// parseEntryID parses a time entry ID argument.
+func parseEntryID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid entry ID %q", arg)
+	}
+	return id, nil
+}
+
+// parseStartedAt parses a start time given on the command line, either
+// RFC 3339 or a local "YYYY-MM-DD HH:MM". It returns the time in UTC in the
+// format FreshBooks expects, and in the local time zone without an offset,
+// the way log records it.
+func parseStartedAt(s string) (utc, local string, err error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+			if t, err = time.ParseInLocation(layout, s, time.Local); err == nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("invalid start time %q (expected YYYY-MM-DD HH:MM or RFC 3339)", s)
+	}
+	return t.UTC().Format("2006-01-02T15:04:05Z"), t.Local().Format("2006-01-02T15:04:05"), nil
+}
+
+// describeEntry summarizes e on one line for messages and prompts.
+func describeEntry(e *api.TimeEntry) string {
+	return fmt.Sprintf("#%d (%s, %.2fh, %q)", e.ID, splitDateTime(entryStart(*e)), float64(e.Duration)/3600, e.Note)
+}
+
+func entriesEditCmd() *cobra.Command {
+	var (
+		note      string
+		length    string
+		client    int
+		project   int
+		service   int
+		billable  bool
+		startedAt string
+		force     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "edit <id>",
+		Short: "Change a time entry",
+		Long: `Change a time entry. Only the fields given as flags are changed.
+
+Entries that are already billed are not edited unless --force is given,
+since the invoice they are on does not change with them.`,
+		Example: `  freshtime entries edit 123 -m "Fix typo in note"
+  freshtime entries edit 123 --duration 1h15m --started-at "2026-02-10 09:30"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseEntryID(args[0])
+			if err != nil {
+				return err
+			}
+			var u api.TimeEntryUpdate
+			flags := cmd.Flags()
+			if flags.Changed("message") {
+				u.Note = &note
+			}
+			if flags.Changed("duration") {
+				d, err := duration.Parse(length)
+				if err != nil {
+					return err
+				}
+				seconds := int(d / time.Second)
+				u.Duration = &seconds
+			}
+			if flags.Changed("client") {
+				u.ClientID = &client
+			}
+			if flags.Changed("project") {
+				u.ProjectID = &project
+			}
+			if flags.Changed("service") {
+				u.ServiceID = &service
+			}
+			if flags.Changed("billable") {
+				u.Billable = &billable
+			}
+			if flags.Changed("started-at") {
+				utc, local, err := parseStartedAt(startedAt)
+				if err != nil {
+					return err
+				}
+				u.StartedAt, u.LocalStartedAt = &utc, &local
+			}
+			if u == (api.TimeEntryUpdate{}) {
+				return fmt.Errorf("nothing to change; give at least one of --message, --duration, --client, --project, --service, --billable or --started-at")
+			}
+			return runEntriesEdit(cmd.Context(), id, u, force)
+		},
+	}
+
+	cmd.Flags().StringVarP(&note, "message", "m", "", "New note")
+	cmd.Flags().StringVarP(&length, "duration", "d", "", "New duration (e.g. 1h30m, 1.5h, 90m or 1:30)")
+	cmd.Flags().IntVar(&client, "client", 0, "New client ID")
+	cmd.Flags().IntVar(&project, "project", 0, "New project ID (0 to clear)")
+	cmd.Flags().IntVar(&service, "service", 0, "New service ID (0 to clear)")
+	cmd.Flags().BoolVar(&billable, "billable", false, "Mark as billable (--billable=false for non-billable)")
+	cmd.Flags().StringVar(&startedAt, "started-at", "", `New start time ("YYYY-MM-DD HH:MM" local time, or RFC 3339)`)
+	cmd.Flags().BoolVar(&force, "force", false, "Edit the entry even if it is already billed")
+
+	return cmd
+}
+
+func runEntriesEdit(ctx context.Context, id int, u api.TimeEntryUpdate, force bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	http := api.NewClient(cfg)
+	entry, err := api.GetTimeEntryContext(ctx, http, cfg.BusinessID, id)
+	if err != nil {
+		return fmt.Errorf("failed to get time entry #%d: %w", id, err)
+	}
+	if entry.Billed {
+		if !force {
+			return fmt.Errorf("entry #%d is already billed; editing it will not change its invoice. Use --force to edit it anyway", id)
+		}
+		fmt.Fprintf(os.Stderr, "warning: entry #%d is already billed; its invoice is not updated\n", id)
+	}
+
+	updated, err := api.UpdateTimeEntryContext(ctx, http, cfg.BusinessID, *entry, u)
+	if err != nil {
+		return fmt.Errorf("failed to update time entry #%d: %w", id, err)
+	}
+	fmt.Printf("Updated entry %s\n", describeEntry(updated))
+	return nil
+}
+
+func entriesDeleteCmd() *cobra.Command {
+	var yes, force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Delete a time entry",
+		Long: `Delete a time entry after asking for confirmation.
+
+Entries that are already billed are not deleted unless --force is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseEntryID(args[0])
+			if err != nil {
+				return err
+			}
+			return runEntriesDelete(cmd.Context(), id, os.Stdin, yes, force)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Delete without asking for confirmation")
+	cmd.Flags().BoolVar(&force, "force", false, "Delete the entry even if it is already billed")
+
+	return cmd
+}
+
+func runEntriesDelete(ctx context.Context, id int, in io.Reader, yes, force bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	http := api.NewClient(cfg)
+	entry, err := api.GetTimeEntryContext(ctx, http, cfg.BusinessID, id)
+	if err != nil {
+		return fmt.Errorf("failed to get time entry #%d: %w", id, err)
+	}
+	if entry.Billed && !force {
+		return fmt.Errorf("entry #%d is already billed; deleting it will not change its invoice. Use --force to delete it anyway", id)
+	}
+
+	if !yes {
+		fmt.Printf("Delete entry %s? [y/N] ", describeEntry(entry))
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Not deleted.")
+			return nil
+		}
+	}
+
+	if err := api.DeleteTimeEntryContext(ctx, http, cfg.BusinessID, id); err != nil {
+		return fmt.Errorf("failed to delete time entry #%d: %w", id, err)
+	}
+	fmt.Printf("Deleted entry #%d.\n", id)
+	return nil
+}
@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/fakefb"
+	"github.com/hev/freshtime/internal/format"
+)
+
+func TestEntriesList(t *testing.T) {
+	fake := useFake(t)
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	globex := fake.AddClient(fakefb.Client{Organization: "Globex"})
+	site := fake.AddProject(fakefb.Project{Title: "Website", ClientID: acme})
+	fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, ProjectID: site, Duration: 3600, StartedAt: "2026-02-09T15:00:00Z", Note: "Review PR", Billable: true})
+	fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, Duration: 1800, StartedAt: "2026-02-10T15:00:00Z", Note: "Standup", Billable: true, Billed: true})
+	fake.AddTimeEntry(fakefb.TimeEntry{ClientID: globex, Duration: 5400, StartedAt: "2026-02-11T15:00:00Z", Note: "review design", Billable: true})
+	fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, Duration: 600, StartedAt: "2026-03-01T15:00:00Z", Note: "Out of range"})
+
+	unbilled := false
+	list := func(q *entryQuery) []format.EntryRow {
+		t.Helper()
+		q.filter.StartedFrom, q.filter.StartedTo = "2026-02-01", "2026-02-28"
+		out, err := captureStdout(t, func() error {
+			return runEntriesList(context.Background(), q, true)
+		})
+		if err != nil {
+			t.Fatalf("runEntriesList: %v", err)
+		}
+		var rows []format.EntryRow
+		if err := json.Unmarshal([]byte(out), &rows); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, out)
+		}
+		return rows
+	}
+	notes := func(rows []format.EntryRow) []string {
+		var notes []string
+		for _, r := range rows {
+			notes = append(notes, r.Note)
+		}
+		return notes
+	}
+
+	rows := list(&entryQuery{})
+	if got := strings.Join(notes(rows), ","); got != "review design,Standup,Review PR" {
+		t.Errorf("all entries, newest first: got %s", got)
+	}
+	if r := rows[2]; r.Client != "Acme Corp" || r.Project != "Website" || r.Date != "2026-02-09" || r.Hours != 1 {
+		t.Errorf("resolved row = %+v", r)
+	}
+
+	for name, tt := range map[string]struct {
+		q    *entryQuery
+		want string
+	}{
+		"client":   {&entryQuery{filter: api.TimeEntryFilter{ClientID: acme}}, "Standup,Review PR"},
+		"unbilled": {&entryQuery{filter: api.TimeEntryFilter{Billed: &unbilled}}, "review design,Review PR"},
+		"note":     {&entryQuery{note: "REVIEW"}, "review design,Review PR"},
+		"match":    {&entryQuery{match: regexp.MustCompile(`^Review`)}, "Review PR"},
+	} {
+		if got := strings.Join(notes(list(tt.q)), ","); got != tt.want {
+			t.Errorf("%s: got %s, want %s", name, got, tt.want)
+		}
+	}
+}
+
+func TestEntryDateRange(t *testing.T) {
+	now := time.Date(2026, 2, 15, 9, 0, 0, 0, time.Local)
+	for _, tt := range []struct {
+		from, to   string
+		start, end string
+		err        bool
+	}{
+		{"", "", "2026-02-09", "2026-02-15", false},
+		{"2026-01-01", "", "2026-01-01", "2026-02-15", false},
+		{"", "2026-01-31", "2026-01-25", "2026-01-31", false},
+		{"2026-02-20", "2026-02-10", "", "", true},
+		{"yesterday", "", "", "", true},
+	} {
+		start, end, err := entryDateRange(tt.from, tt.to, now)
+		if (err != nil) != tt.err || start != tt.start || end != tt.end {
+			t.Errorf("entryDateRange(%q, %q) = %s, %s, %v", tt.from, tt.to, start, end, err)
+		}
+	}
+}
+
+func TestEntriesEditAndDelete(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	id := fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, Duration: 3600, StartedAt: "2026-02-10T15:00:00Z", Note: "Reveiw", Billable: true})
+	billedID := fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, Duration: 1800, StartedAt: "2026-02-11T15:00:00Z", Note: "Invoiced", Billable: true, Billed: true})
+
+	note, seconds := "Review", 5400
+	if _, err := captureStdout(t, func() error {
+		return runEntriesEdit(ctx, id, api.TimeEntryUpdate{Note: &note, Duration: &seconds}, false)
+	}); err != nil {
+		t.Fatalf("runEntriesEdit: %v", err)
+	}
+	entries := fake.TimeEntries()
+	if e := entries[0]; e.Note != "Review" || e.Duration != 5400 || e.StartedAt != "2026-02-10T15:00:00Z" || !e.Billable {
+		t.Errorf("edited entry = %+v", e)
+	}
+
+	// Billed entries need --force.
+	err := runEntriesEdit(ctx, billedID, api.TimeEntryUpdate{Note: &note}, false)
+	if err == nil || !strings.Contains(err.Error(), "already billed") {
+		t.Errorf("expected billed entry to be refused, got %v", err)
+	}
+	if err := runEntriesDelete(ctx, billedID, strings.NewReader("y\n"), false, false); err == nil {
+		t.Error("expected deleting a billed entry to be refused")
+	}
+
+	// Declining the prompt keeps the entry.
+	if _, err := captureStdout(t, func() error {
+		return runEntriesDelete(ctx, id, strings.NewReader("n\n"), false, false)
+	}); err != nil {
+		t.Fatalf("runEntriesDelete: %v", err)
+	}
+	if n := len(fake.TimeEntries()); n != 2 {
+		t.Fatalf("expected 2 entries after declining, got %d", n)
+	}
+	if _, err := captureStdout(t, func() error {
+		return runEntriesDelete(ctx, id, strings.NewReader("yes\n"), false, false)
+	}); err != nil {
+		t.Fatalf("runEntriesDelete: %v", err)
+	}
+	if entries := fake.TimeEntries(); len(entries) != 1 || entries[0].ID != billedID {
+		t.Errorf("expected only the billed entry left, got %+v", entries)
+	}
+}
+
+func TestParseStartedAt(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("PST", -8*3600)
+	t.Cleanup(func() { time.Local = local })
+
+	for _, tt := range []struct {
+		in, utc, local string
+	}{
+		{"2026-02-10T09:30:00+01:00", "2026-02-10T08:30:00Z", "2026-02-10T00:30:00"},
+		{"2026-02-10 18:30", "2026-02-11T02:30:00Z", "2026-02-10T18:30:00"},
+		{"2026-02-10T18:30", "2026-02-11T02:30:00Z", "2026-02-10T18:30:00"},
+	} {
+		utc, local, err := parseStartedAt(tt.in)
+		if err != nil || utc != tt.utc || local != tt.local {
+			t.Errorf("parseStartedAt(%q) = %q, %q, %v; want %q, %q", tt.in, utc, local, err, tt.utc, tt.local)
+		}
+	}
+	if _, _, err := parseStartedAt("tomorrow"); err == nil {
+		t.Error("expected an error for an unparseable time")
+	}
+}
+
+func TestEntriesEditStartedAtLandsOnLocalDay(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("PST", -8*3600)
+	t.Cleanup(func() { time.Local = local })
+
+	fake := useFake(t)
+	ctx := context.Background()
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	id := fake.AddTimeEntry(fakefb.TimeEntry{ClientID: acme, Duration: 3600, StartedAt: "2026-02-09T17:00:00Z", LocalStartedAt: "2026-02-09T09:00:00", Note: "Deploy"})
+
+	// Editing other fields keeps the local start.
+	note := "Late deploy"
+	if _, err := captureStdout(t, func() error {
+		return runEntriesEdit(ctx, id, api.TimeEntryUpdate{Note: &note}, false)
+	}); err != nil {
+		t.Fatalf("runEntriesEdit: %v", err)
+	}
+	if e := fake.TimeEntries()[0]; e.LocalStartedAt != "2026-02-09T09:00:00" {
+		t.Errorf("after note edit: local start = %q", e.LocalStartedAt)
+	}
+
+	// 18:30 on Tuesday in California is already Wednesday in UTC.
+	utc, localStart, err := parseStartedAt("2026-02-10 18:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := captureStdout(t, func() error {
+		return runEntriesEdit(ctx, id, api.TimeEntryUpdate{StartedAt: &utc, LocalStartedAt: &localStart}, false)
+	}); err != nil {
+		t.Fatalf("runEntriesEdit: %v", err)
+	}
+	if e := fake.TimeEntries()[0]; e.StartedAt != "2026-02-11T02:30:00Z" || e.LocalStartedAt != "2026-02-10T18:30:00" {
+		t.Errorf("moved entry = %+v", e)
+	}
+}
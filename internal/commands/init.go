@@ -2,7 +2,10 @@ package commands
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strconv"
@@ -16,26 +19,72 @@ import (
 
 // InitCmd returns the init command.
 func InitCmd() *cobra.Command {
-	return &cobra.Command{
+	var show bool
+	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize .freshtime.json in the current directory",
+		Long: `Initialize .freshtime.json in the current directory.
+
+freshtime looks for .freshtime.json in the current directory and its parents,
+up to the root of the git repository. A file nearer the current directory
+overrides the keys it sets and inherits the rest; add "root": true to stop
+inheriting from parent directories.
+
+Re-running init in a directory that already has .freshtime.json changes only
+its client, project and service; other settings are kept.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runInit()
+			if show {
+				return runInitShow()
+			}
+			return runInit(cmd.Context(), os.Stdin)
 		},
 	}
+	cmd.Flags().BoolVar(&show, "show", false, "Print the .freshtime.json files that apply here and the merged settings")
+	return cmd
 }
 
-func runInit() error {
+// runInitShow prints the project files used in the current directory, in
+// the order they are merged, and the settings they produce.
+func runInitShow() error {
+	pc, err := config.LoadProjectConfigFromCwd()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Files (later override earlier):")
+	for _, path := range pc.Files() {
+		fmt.Printf("  %s\n", path)
+	}
+	fmt.Println()
+	for _, e := range pc.Values() {
+		fmt.Printf("%-14s = %-24s # %s\n", e.Key, e.Value, pc.Source(e.Key))
+	}
+	return nil
+}
+
+func runInit(ctx context.Context, in io.Reader) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return err
 	}
 
+	// Keep the other settings of an existing file; only the IDs change.
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	pc, err := config.LoadProjectConfig(cwd)
+	if errors.Is(err, config.ErrNoProjectConfig) {
+		pc, err = &config.ProjectConfig{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
 	http := api.NewClient(cfg)
-	reader := bufio.NewReader(os.Stdin)
+	reader := bufio.NewReader(in)
 
 	// Pick client
-	clients, err := api.ListClients(http, cfg.AccountID)
+	clients, err := api.ListClientsContext(ctx, http, cfg.AccountID)
 	if err != nil {
 		return fmt.Errorf("failed to list clients: %w", err)
 	}
@@ -45,7 +94,7 @@ func runInit() error {
 	}
 
 	// Pick project
-	projects, err := api.ListProjects(http, cfg.BusinessID, clientID)
+	projects, err := api.ListProjectsContext(ctx, http, cfg.BusinessID, clientID)
 	if err != nil {
 		return fmt.Errorf("failed to list projects: %w", err)
 	}
@@ -60,7 +109,7 @@ func runInit() error {
 	}
 
 	// Pick service
-	services, err := api.ListServices(http, cfg.BusinessID)
+	services, err := api.ListServicesContext(ctx, http, cfg.BusinessID)
 	if err != nil {
 		return fmt.Errorf("failed to list services: %w", err)
 	}
@@ -74,16 +123,7 @@ func runInit() error {
 		fmt.Println("No services found, skipping.")
 	}
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	pc := &config.ProjectConfig{
-		ClientID:  clientID,
-		ProjectID: projectID,
-		ServiceID: serviceID,
-	}
+	pc.ClientID, pc.ProjectID, pc.ServiceID = clientID, projectID, serviceID
 	if err := config.SaveProjectConfig(cwd, pc); err != nil {
 		return fmt.Errorf("failed to write %s: %w", config.ProjectConfigFile, err)
 	}
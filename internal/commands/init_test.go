@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/fakefb"
+)
+
+func TestInitKeepsOtherSettings(t *testing.T) {
+	fake := useFake(t)
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	site := fake.AddProject(fakefb.Project{Title: "Website", ClientID: acme})
+	dev := fake.AddService(fakefb.Service{Name: "Development"})
+
+	// The file picks the "work" profile, so it has to exist.
+	if err := config.Save(&config.Config{Name: "work", AccessToken: "test-token", AccountID: fake.AccountID, BusinessID: fake.BusinessID}); err != nil {
+		t.Fatal(err)
+	}
+	notBillable := false
+	if err := config.SaveProjectConfig(".", &config.ProjectConfig{
+		ClientID:     1,
+		ProjectID:    2,
+		Profile:      "work",
+		Billable:     &notBillable,
+		Rate:         "120",
+		Currency:     "EUR",
+		NoteTemplate: "[{{.Branch}}] {{.Message}}",
+		Tags:         []string{"ops"},
+		Rounding:     &config.Rounding{Increment: "15m", Mode: "up"},
+		Root:         true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runInit(context.Background(), strings.NewReader("1\n1\n1\n"))
+	}); err != nil {
+		t.Fatalf("runInit: %v", err)
+	}
+
+	pc, err := config.LoadProjectConfig(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.ClientID != acme || pc.ProjectID != site || pc.ServiceID != dev {
+		t.Errorf("IDs = %d/%d/%d, want %d/%d/%d", pc.ClientID, pc.ProjectID, pc.ServiceID, acme, site, dev)
+	}
+	if pc.Profile != "work" || pc.Billable == nil || *pc.Billable || pc.Rate != "120" || pc.Currency != "EUR" ||
+		pc.NoteTemplate != "[{{.Branch}}] {{.Message}}" || !slices.Equal(pc.Tags, []string{"ops"}) ||
+		pc.Rounding == nil || pc.Rounding.Increment != "15m" || !pc.Root {
+		t.Errorf("other settings were not kept: %+v", pc)
+	}
+}
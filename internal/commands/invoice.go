@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"time"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/hev/freshtime/internal/api"
 	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/duration"
 )
 
 // InvoiceCmd returns the invoice command.
@@ -17,29 +19,43 @@ func InvoiceCmd() *cobra.Command {
 	var currency string
 	var dryRun bool
 	var notes string
+	var noRound bool
 
 	cmd := &cobra.Command{
 		Use:   "invoice <client-id>",
 		Short: "Create an invoice for all unbilled time entries for a client",
-		Args:  cobra.ExactArgs(1),
+		Long: `Create an invoice for all unbilled time entries for a client.
+
+Project files (.freshtime.json in the current directory and each parent up
+to the git repository root, merged) set the rate, currency and rounding
+only if their client_id is the client being invoiced.
+
+Each entry is invoiced with its duration rounded by the first rule found:
+rounding in .freshtime.json, client_rounding.<client-id>.* in the config,
+then rounding.* in the config. log and stop use the same order.
+--dry-run shows what rounding changes.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			clientID, err := strconv.Atoi(args[0])
 			if err != nil {
 				return fmt.Errorf("invalid client ID: %w", err)
 			}
-			return runInvoice(clientID, rate, currency, dryRun, notes)
+			return runInvoice(cmd.Context(), clientID, rate, currency, dryRun, noRound, notes)
 		},
 	}
 
-	cmd.Flags().StringVar(&rate, "rate", "", "Override the hourly rate for this run")
-	cmd.Flags().StringVar(&currency, "currency", "", "Override currency code (default: config or USD)")
+	cmd.Flags().StringVar(&rate, "rate", "", "Override the hourly rate for this run (default: .freshtime.json for this client, then client_rates)")
+	cmd.Flags().StringVar(&currency, "currency", "", "Override currency code (default: .freshtime.json, then config, then USD)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be invoiced without creating it")
 	cmd.Flags().StringVar(&notes, "notes", "", "Add notes to the invoice")
+	cmd.Flags().BoolVar(&noRound, "no-round", false, "Invoice durations as logged, ignoring rounding rules")
 
 	return cmd
 }
 
-func buildInvoiceLines(entries []api.TimeEntry, rate, currency string) []api.InvoiceLine {
+// buildInvoiceLines returns one invoice line per entry, with the entry's
+// duration rounded by rule as the quantity in hours.
+func buildInvoiceLines(entries []api.TimeEntry, rule duration.Rounding, rate, currency string) []api.InvoiceLine {
 	lines := make([]api.InvoiceLine, 0, len(entries))
 	for _, entry := range entries {
 		name := entry.Note
@@ -54,13 +70,22 @@ func buildInvoiceLines(entries []api.TimeEntry, rate, currency string) []api.Inv
 			Type:        0,
 			Name:        name,
 			Description: desc,
-			Qty:         fmt.Sprintf("%.2f", float64(entry.Duration)/3600),
+			Qty:         fmt.Sprintf("%.2f", float64(rule.Round(entry.Duration))/3600),
 			UnitCost:    api.InvoiceAmount{Amount: rate, Code: currency},
 		})
 	}
 	return lines
 }
 
+// roundingDelta describes how rounding changed a duration, e.g.
+// " (logged 1.40h, +0.10h)", or returns "" when it did not.
+func roundingDelta(logged, rounded int) string {
+	if logged == rounded {
+		return ""
+	}
+	return fmt.Sprintf(" (logged %.2fh, %+.2fh)", float64(logged)/3600, float64(rounded-logged)/3600)
+}
+
 func splitDateTime(dt string) string {
 	if len(dt) >= 10 {
 		return dt[:10]
@@ -68,7 +93,7 @@ func splitDateTime(dt string) string {
 	return dt
 }
 
-func runInvoice(clientID int, rate, currency string, dryRun bool, notes string) error {
+func runInvoice(ctx context.Context, clientID int, rate, currency string, dryRun, noRound bool, notes string) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return err
@@ -76,7 +101,7 @@ func runInvoice(clientID int, rate, currency string, dryRun bool, notes string)
 
 	http := api.NewClient(cfg)
 
-	entries, err := api.ListUnbilledEntries(http, cfg.BusinessID, clientID)
+	entries, err := api.ListUnbilledEntriesContext(ctx, http, cfg.BusinessID, clientID)
 	if err != nil {
 		return err
 	}
@@ -86,15 +111,31 @@ func runInvoice(clientID int, rate, currency string, dryRun bool, notes string)
 		return nil
 	}
 
+	// A .freshtime.json for exactly this client can set its rate,
+	// currency and rounding.
+	pc, err := config.LoadProjectDefaults()
+	if err != nil {
+		return err
+	}
+	if pc.ClientID != clientID {
+		pc = &config.ProjectConfig{}
+	}
+
 	// Resolve rate
+	if rate == "" {
+		rate = pc.Rate
+	}
 	if rate == "" {
 		rate = cfg.ClientRates[strconv.Itoa(clientID)]
 	}
 	if rate == "" {
-		return fmt.Errorf("no rate configured for client %d. Use --rate <amount> or set client_rates.%d in config", clientID, clientID)
+		return fmt.Errorf("no rate configured for client %d. Use --rate <amount> or `freshtime config set client_rates.%d <amount>`", clientID, clientID)
 	}
 
 	// Resolve currency
+	if currency == "" {
+		currency = pc.Currency
+	}
 	if currency == "" {
 		currency = cfg.DefaultCurrency
 	}
@@ -102,11 +143,21 @@ func runInvoice(clientID int, rate, currency string, dryRun bool, notes string)
 		currency = "USD"
 	}
 
-	lines := buildInvoiceLines(entries, rate, currency)
+	// Resolve rounding
+	var rule duration.Rounding
+	rounding, roundingSource := cfg.RoundingFor(clientID, pc)
+	if !noRound {
+		if rule, err = rounding.Rule(); err != nil {
+			return fmt.Errorf("invalid rounding in %s: %w", roundingSource, err)
+		}
+	}
 
-	var totalSeconds int
+	lines := buildInvoiceLines(entries, rule, rate, currency)
+
+	var loggedSeconds, totalSeconds int
 	for _, e := range entries {
-		totalSeconds += e.Duration
+		loggedSeconds += e.Duration
+		totalSeconds += rule.Round(e.Duration)
 	}
 	totalHours := float64(totalSeconds) / 3600
 	rateFloat, _ := strconv.ParseFloat(rate, 64)
@@ -116,12 +167,16 @@ func runInvoice(clientID int, rate, currency string, dryRun bool, notes string)
 		fmt.Println("Dry run — no invoice created.")
 		fmt.Println()
 		fmt.Printf("Entries:  %d\n", len(entries))
-		fmt.Printf("Hours:   %.2f\n", totalHours)
+		fmt.Printf("Hours:   %.2f%s\n", totalHours, roundingDelta(loggedSeconds, totalSeconds))
+		if !rule.IsZero() {
+			fmt.Printf("Rounding: %s (%s)\n", rule, roundingSource)
+		}
 		fmt.Printf("Rate:    %s %s/hr\n", rate, currency)
 		fmt.Printf("Total:   %.2f %s\n\n", totalAmount, currency)
 		fmt.Println("Line items:")
-		for _, line := range lines {
-			fmt.Printf("  %s  %sh  %s\n", line.Description, line.Qty, line.Name)
+		for i, line := range lines {
+			e := entries[i]
+			fmt.Printf("  %s  %sh  %s%s\n", line.Description, line.Qty, line.Name, roundingDelta(e.Duration, rule.Round(e.Duration)))
 		}
 		return nil
 	}
@@ -137,7 +192,7 @@ func runInvoice(clientID int, rate, currency string, dryRun bool, notes string)
 		},
 	}
 
-	invoice, err := api.CreateInvoice(http, cfg.AccountID, req)
+	invoice, err := api.CreateInvoiceContext(ctx, http, cfg.AccountID, req)
 	if err != nil {
 		return err
 	}
@@ -148,14 +203,14 @@ func runInvoice(clientID int, rate, currency string, dryRun bool, notes string)
 	fmt.Printf("Hours:   %.2f\n", totalHours)
 	fmt.Printf("Total:   %s %s\n", invoice.Amount.Amount, invoice.Amount.Code)
 
-	shareLink, err := api.GetShareLink(http, cfg.AccountID, invoice.InvoiceID)
+	shareLink, err := api.GetShareLinkContext(ctx, http, cfg.AccountID, invoice.InvoiceID)
 	if err != nil || shareLink == "" {
 		fmt.Println("Link:    (share link unavailable — may need invoices:read scope)")
 	} else {
 		fmt.Printf("Link:    %s\n", shareLink)
 	}
 
-	if err := api.MarkEntriesAsBilled(http, cfg.BusinessID, entries); err != nil {
+	if err := api.MarkEntriesAsBilledContext(ctx, http, cfg.BusinessID, entries); err != nil {
 		fmt.Printf("Warning: Failed to mark entries as billed — %s\n", err)
 	} else {
 		fmt.Printf("Billed:  %d entries marked as billed\n", len(entries))
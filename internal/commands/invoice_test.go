@@ -2,8 +2,10 @@ package commands
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/duration"
 )
 
 var sampleEntries = []api.TimeEntry{
@@ -29,7 +31,7 @@ var sampleEntries = []api.TimeEntry{
 
 func TestBuildInvoiceLines(t *testing.T) {
 	t.Run("creates one line per entry with correct fields", func(t *testing.T) {
-		lines := buildInvoiceLines(sampleEntries, "150.00", "USD")
+		lines := buildInvoiceLines(sampleEntries, duration.Rounding{}, "150.00", "USD")
 
 		if len(lines) != 2 {
 			t.Fatalf("expected 2 lines, got %d", len(lines))
@@ -56,7 +58,7 @@ func TestBuildInvoiceLines(t *testing.T) {
 	})
 
 	t.Run("uses Consulting when note is empty", func(t *testing.T) {
-		lines := buildInvoiceLines(sampleEntries, "150.00", "USD")
+		lines := buildInvoiceLines(sampleEntries, duration.Rounding{}, "150.00", "USD")
 		if lines[1].Name != "Consulting" {
 			t.Errorf("name = %q, want %q", lines[1].Name, "Consulting")
 		}
@@ -74,7 +76,7 @@ func TestBuildInvoiceLines(t *testing.T) {
 				Billable:       true,
 			},
 		}
-		lines := buildInvoiceLines(entries, "100.00", "CAD")
+		lines := buildInvoiceLines(entries, duration.Rounding{}, "100.00", "CAD")
 		if lines[0].Qty != "0.75" {
 			t.Errorf("qty = %q, want %q", lines[0].Qty, "0.75")
 		}
@@ -82,6 +84,18 @@ func TestBuildInvoiceLines(t *testing.T) {
 			t.Errorf("unit_cost.code = %q, want %q", lines[0].UnitCost.Code, "CAD")
 		}
 	})
+
+	t.Run("rounds quantities by the rounding rule", func(t *testing.T) {
+		entries := []api.TimeEntry{
+			{ID: 1, Duration: 7*60 + 30}, // 7.5 minutes
+			{ID: 2, Duration: 40 * 60},
+		}
+		rule := duration.Rounding{Increment: 6 * time.Minute, Mode: duration.Up, Minimum: 15 * time.Minute}
+		lines := buildInvoiceLines(entries, rule, "100.00", "USD")
+		if lines[0].Qty != "0.30" || lines[1].Qty != "0.70" {
+			t.Errorf("qty = %q, %q; want 0.30, 0.70", lines[0].Qty, lines[1].Qty)
+		}
+	})
 }
 
 func TestSplitDateTime(t *testing.T) {
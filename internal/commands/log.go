@@ -1,112 +1,290 @@
 package commands
 
 import (
+	"context"
 	"fmt"
-	"regexp"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hev/freshtime/internal/api"
 	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/duration"
 )
 
+// entryOptions are the flags that choose where a new time entry goes and
+// whether it is billable. Unset flags fall back to .freshtime.json.
+type entryOptions struct {
+	clientID   int
+	projectID  int
+	serviceID  int
+	billable   bool
+	noBillable bool
+}
+
+func (o *entryOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&o.clientID, "client", 0, "Client ID (overrides .freshtime.json)")
+	cmd.Flags().IntVar(&o.projectID, "project", 0, "Project ID (overrides .freshtime.json)")
+	cmd.Flags().IntVar(&o.serviceID, "service", 0, "Service ID (overrides .freshtime.json)")
+	cmd.Flags().BoolVar(&o.billable, "billable", false, "Mark as billable (overrides .freshtime.json)")
+	cmd.Flags().BoolVar(&o.noBillable, "no-billable", false, "Mark as non-billable")
+	cmd.MarkFlagsMutuallyExclusive("billable", "no-billable")
+}
+
+// applyProject fills in the IDs not given as flags from pc and reports
+// whether the entry is billable.
+func (o *entryOptions) applyProject(pc *config.ProjectConfig) (billable bool, err error) {
+	if o.clientID == 0 {
+		o.clientID = pc.ClientID
+	}
+	if o.projectID == 0 {
+		o.projectID = pc.ProjectID
+	}
+	if o.serviceID == 0 {
+		o.serviceID = pc.ServiceID
+	}
+	if o.clientID == 0 {
+		return false, fmt.Errorf("no client specified. Use --client or run `freshtime init` to create .freshtime.json")
+	}
+
+	switch {
+	case o.billable:
+		return true, nil
+	case o.noBillable:
+		return false, nil
+	case pc.Billable != nil:
+		return *pc.Billable, nil
+	}
+	return true, nil
+}
+
+// logWhen holds the flags that say when logged work happened.
+type logWhen struct {
+	date     string // YYYY-MM-DD, today, yesterday or a weekday
+	at       string // start time of day
+	from, to string // start and end time of day, instead of a duration
+	duration string
+}
+
+// backdated reports whether the user said when the work happened, rather
+// than logging it as starting now.
+func (w logWhen) backdated() bool {
+	return w.date != "" || w.at != "" || w.from != ""
+}
+
+// resolve returns when the work started, in now's time zone, and how many
+// seconds it took. A --date without a start time starts at 09:00.
+func (w logWhen) resolve(now time.Time) (time.Time, int, error) {
+	day, err := parseDate(w.date, now)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	var start time.Time
+	var seconds int
+	switch {
+	case w.from != "" || w.to != "":
+		if w.from == "" || w.to == "" {
+			return time.Time{}, 0, fmt.Errorf("--from and --to must be used together")
+		}
+		if start, err = atClock(day, w.from); err != nil {
+			return time.Time{}, 0, err
+		}
+		end, err := atClock(day, w.to)
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		if !end.After(start) {
+			return time.Time{}, 0, fmt.Errorf("--to %s must be after --from %s", w.to, w.from)
+		}
+		seconds = int(end.Sub(start).Seconds())
+	case w.duration == "":
+		return time.Time{}, 0, fmt.Errorf("a duration is required: use --duration, or --from and --to")
+	default:
+		d, err := duration.Parse(w.duration)
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		seconds = int(d / time.Second)
+		switch {
+		case w.at != "":
+			start, err = atClock(day, w.at)
+		case w.date != "":
+			start, err = atClock(day, "09:00")
+		default:
+			start = now
+		}
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+	}
+
+	if start.After(now) {
+		return time.Time{}, 0, fmt.Errorf("cannot log time starting in the future (%s)", start.Format("Mon Jan 2 15:04"))
+	}
+	return start, seconds, nil
+}
+
 // LogCmd returns the log command.
 func LogCmd() *cobra.Command {
 	var (
-		message   string
-		duration  string
-		client    int
-		project   int
-		service   int
-		noBillable bool
+		message string
+		when    logWhen
+		noRound bool
+		opts    entryOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "log",
 		Short: "Log a time entry",
+		Long: `Log a time entry.
+
+By default the work is logged as starting now. Use --date and --at to log
+earlier work, or --from and --to instead of --duration:
+
+  freshtime log -m "Standup" -d 15m --date yesterday --at 09:30
+  freshtime log -m "Review" --date mon --from 14:00 --to 15:30
+
+Dates and times are in your local time zone.
+
+The client, project, service, billable flag, note template and tags default
+to the settings in .freshtime.json; flags take precedence. The duration is
+rounded by the first rule found: rounding in .freshtime.json if its
+client_id is the entry's client, client_rounding.<client-id>.* in the
+config, then rounding.* in the config. invoice uses the same order.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runLog(message, duration, client, project, service, noBillable)
+			return runLog(cmd.Context(), message, when, noRound, &opts)
 		},
 	}
 
 	cmd.Flags().StringVarP(&message, "message", "m", "", "Note for the time entry (required)")
-	cmd.Flags().StringVarP(&duration, "duration", "d", "", "Duration (e.g. 2h, 30m, 1h30m)")
-	cmd.Flags().IntVar(&client, "client", 0, "Client ID (overrides .freshtime.json)")
-	cmd.Flags().IntVar(&project, "project", 0, "Project ID (overrides .freshtime.json)")
-	cmd.Flags().IntVar(&service, "service", 0, "Service ID (overrides .freshtime.json)")
-	cmd.Flags().BoolVar(&noBillable, "no-billable", false, "Mark as non-billable")
+	cmd.Flags().StringVarP(&when.duration, "duration", "d", "", "Duration (e.g. 1h30m, 1.5h, 90m or 1:30)")
+	cmd.Flags().StringVar(&when.date, "date", "", "Day the work happened (YYYY-MM-DD, today, yesterday, or a weekday like mon)")
+	cmd.Flags().StringVar(&when.at, "at", "", "Start time (e.g. 09:30 or 2pm; default 09:00 with --date, otherwise now)")
+	cmd.Flags().StringVar(&when.from, "from", "", "Start time, used with --to instead of --duration")
+	cmd.Flags().StringVar(&when.to, "to", "", "End time, used with --from instead of --duration")
+	cmd.Flags().BoolVar(&noRound, "no-round", false, "Log the duration as given, ignoring rounding rules")
+	opts.addFlags(cmd)
 	cmd.MarkFlagRequired("message")
-	cmd.MarkFlagRequired("duration")
+	cmd.MarkFlagsOneRequired("duration", "from")
+	cmd.MarkFlagsMutuallyExclusive("duration", "from")
+	cmd.MarkFlagsMutuallyExclusive("duration", "to")
+	cmd.MarkFlagsMutuallyExclusive("at", "from")
+	cmd.MarkFlagsRequiredTogether("from", "to")
 
 	return cmd
 }
 
-func runLog(message, duration string, clientID, projectID, serviceID int, noBillable bool) error {
+func runLog(ctx context.Context, message string, when logWhen, noRound bool, opts *entryOptions) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return err
 	}
 
 	// Load project config for defaults
-	pc, _ := config.LoadProjectConfigFromCwd()
-	if pc != nil {
-		if clientID == 0 {
-			clientID = pc.ClientID
-		}
-		if projectID == 0 {
-			projectID = pc.ProjectID
-		}
-		if serviceID == 0 {
-			serviceID = pc.ServiceID
-		}
+	pc, err := config.LoadProjectDefaults()
+	if err != nil {
+		return err
 	}
-
-	if clientID == 0 {
-		return fmt.Errorf("no client specified. Use --client or run `freshtime init` to create .freshtime.json")
+	billable, err := opts.applyProject(pc)
+	if err != nil {
+		return err
 	}
 
-	seconds, err := parseDuration(duration)
+	start, seconds, err := when.resolve(time.Now())
 	if err != nil {
 		return err
 	}
+	rounding, _ := cfg.RoundingFor(opts.clientID, pc)
+	logged, err := roundDuration(seconds, rounding, noRound)
+	if err != nil {
+		return err
+	}
+	note, err := renderNote(pc.NoteTemplate, message, pc.Tags)
+	if err != nil {
+		return fmt.Errorf("invalid note_template: %w", err)
+	}
 
 	http := api.NewClient(cfg)
-	entry, err := api.CreateTimeEntry(http, cfg.BusinessID, api.CreateTimeEntryRequest{
-		ClientID:  clientID,
-		ProjectID: projectID,
-		ServiceID: serviceID,
-		Duration:  seconds,
-		Note:      message,
-		Billable:  !noBillable,
-		StartedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	entry, err := api.CreateTimeEntryContext(ctx, http, cfg.BusinessID, api.CreateTimeEntryRequest{
+		ClientID:       opts.clientID,
+		ProjectID:      opts.projectID,
+		ServiceID:      opts.serviceID,
+		Duration:       logged,
+		Note:           note,
+		Billable:       billable,
+		StartedAt:      start.UTC().Format("2006-01-02T15:04:05Z"),
+		LocalStartedAt: start.Format("2006-01-02T15:04:05"),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create time entry: %w", err)
 	}
 
-	hours := float64(seconds) / 3600
-	fmt.Printf("Logged %.2fh: %s (entry #%d)\n", hours, message, entry.ID)
+	var on string
+	if when.backdated() {
+		on = start.Format(" on Mon Jan 2 at 15:04")
+	}
+	hours := float64(logged) / 3600
+	fmt.Printf("Logged %.2fh%s%s: %s (entry #%d)\n", hours, roundedFrom(seconds, logged), on, note, entry.ID)
 	return nil
 }
 
-// parseDuration parses a human-friendly duration string like "2h", "30m", "1h30m".
-func parseDuration(s string) (int, error) {
-	re := regexp.MustCompile(`^(?:(\d+)h)?(?:(\d+)m)?$`)
-	matches := re.FindStringSubmatch(s)
-	if matches == nil || (matches[1] == "" && matches[2] == "") {
-		return 0, fmt.Errorf("invalid duration %q (expected format: 2h, 30m, 1h30m)", s)
+// roundDuration applies the rounding rule r to seconds unless noRound is
+// set.
+func roundDuration(seconds int, r *config.Rounding, noRound bool) (int, error) {
+	if noRound {
+		return seconds, nil
+	}
+	rule, err := r.Rule()
+	if err != nil {
+		return 0, fmt.Errorf("invalid rounding: %w", err)
 	}
+	return rule.Round(seconds), nil
+}
 
-	var seconds int
-	if matches[1] != "" {
-		h, _ := strconv.Atoi(matches[1])
-		seconds += h * 3600
+// roundedFrom describes the original duration when rounding changed it.
+func roundedFrom(seconds, logged int) string {
+	if seconds == logged {
+		return ""
+	}
+	return fmt.Sprintf(" (rounded from %s)", duration.Format(time.Duration(seconds)*time.Second))
+}
+
+// parseDate returns midnight, in now's time zone, of the day s names:
+// YYYY-MM-DD, "today", "yesterday", or a weekday ("mon", "monday") meaning
+// its most recent occurrence, today included. An empty s means today.
+func parseDate(s string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "", "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if s == name || s == name[:3] {
+			back := (int(today.Weekday()) - int(wd) + 7) % 7
+			return today.AddDate(0, 0, -back), nil
+		}
+	}
+	day, err := time.ParseInLocation("2006-01-02", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD, today, yesterday or a weekday)", s)
 	}
-	if matches[2] != "" {
-		m, _ := strconv.Atoi(matches[2])
-		seconds += m * 60
+	return day, nil
+}
+
+// atClock returns the time of day s (e.g. "09:30", "14:00", "2pm",
+// "2:30pm") on day, in day's time zone.
+func atClock(day time.Time, s string) (time.Time, error) {
+	clock := strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	for _, layout := range []string{"15:04", "3:04pm", "3pm"} {
+		t, err := time.Parse(layout, clock)
+		if err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+		}
 	}
-	return seconds, nil
+	return time.Time{}, fmt.Errorf("invalid time %q (expected e.g. 09:30, 14:00 or 2pm)", s)
 }
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hev/freshtime/internal/fakefb"
+	"github.com/hev/freshtime/internal/format"
+)
+
+func TestLogWhenResolve(t *testing.T) {
+	pst := time.FixedZone("PST", -8*3600)
+	now := time.Date(2026, 2, 12, 16, 45, 0, 0, pst) // a Thursday
+	for _, tt := range []struct {
+		when    logWhen
+		start   string
+		seconds int
+		err     bool
+	}{
+		{logWhen{duration: "1h"}, "2026-02-12 16:45", 3600, false},
+		{logWhen{duration: "30m", date: "yesterday"}, "2026-02-11 09:00", 1800, false},
+		{logWhen{duration: "30m", date: "mon", at: "2:30pm"}, "2026-02-09 14:30", 1800, false},
+		{logWhen{duration: "30m", date: "thursday", at: "8:15"}, "2026-02-12 08:15", 1800, false},
+		{logWhen{duration: "30m", date: "2026-02-01"}, "2026-02-01 09:00", 1800, false},
+		{logWhen{duration: "30m", at: "10:00"}, "2026-02-12 10:00", 1800, false},
+		{logWhen{from: "09:00", to: "11:30"}, "2026-02-12 09:00", 9000, false},
+		{logWhen{date: "tue", from: "13:00", to: "12:00"}, "", 0, true},
+		{logWhen{from: "09:00"}, "", 0, true},
+		{logWhen{date: "yesterday"}, "", 0, true},
+		{logWhen{duration: "1h", at: "18:00"}, "", 0, true}, // in the future
+		{logWhen{duration: "1h", date: "someday"}, "", 0, true},
+		{logWhen{duration: "1h", at: "25:00"}, "", 0, true},
+	} {
+		start, seconds, err := tt.when.resolve(now)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%+v: expected an error, got %s", tt.when, start)
+			}
+			continue
+		}
+		if err != nil || start.Format("2006-01-02 15:04") != tt.start || seconds != tt.seconds || start.Location() != pst {
+			t.Errorf("%+v: got %s, %d, %v; want %s, %d", tt.when, start, seconds, err, tt.start, tt.seconds)
+		}
+	}
+}
+
+func TestLogBackdatedLandsOnLocalDay(t *testing.T) {
+	// 18:00 on Tuesday in California is already Wednesday in UTC.
+	local := time.Local
+	time.Local = time.FixedZone("PST", -8*3600)
+	t.Cleanup(func() { time.Local = local })
+
+	fake := useFake(t)
+	ctx := context.Background()
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+
+	if _, err := captureStdout(t, func() error {
+		return runLog(ctx, "Late deploy", logWhen{date: "2026-02-10", from: "18:00", to: "19:30"}, false, &entryOptions{clientID: acme})
+	}); err != nil {
+		t.Fatalf("runLog: %v", err)
+	}
+	entries := fake.TimeEntries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if e := entries[0]; e.StartedAt != "2026-02-11T02:00:00Z" || e.LocalStartedAt != "2026-02-10T18:00:00" || e.Duration != 5400 {
+		t.Errorf("logged entry = %+v", e)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runWeekly(ctx, "2026-02-10", true)
+	})
+	if err != nil {
+		t.Fatalf("runWeekly: %v", err)
+	}
+	var summary format.WeeklySummary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("invalid weekly JSON: %v\n%s", err, out)
+	}
+	if c := findClient(summary.Clients, "Acme Corp"); c == nil || c.Daily[1] != 1.5 || c.Daily[2] != 0 {
+		t.Errorf("Acme Corp = %+v, want 1.5h on Tuesday", c)
+	}
+}
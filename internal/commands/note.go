@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// noteData is what a project's note_template can refer to. Branch and
+// Commit are methods so git only runs when the template uses them.
+type noteData struct {
+	Message string
+	Tags    string // e.g. "#backend #ops"
+}
+
+// Branch returns the current git branch, or "" outside a repository.
+func (noteData) Branch() string {
+	return gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// Commit returns the abbreviated hash of HEAD, or "" outside a repository.
+func (noteData) Commit() string {
+	return gitOutput("rev-parse", "--short", "HEAD")
+}
+
+func gitOutput(args ...string) string {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// renderNote builds the note for a time entry from the user's message and
+// the project's note template and tags. Without a template the tags are
+// appended to the message.
+func renderNote(tmpl, message string, tags []string) (string, error) {
+	hashtags := make([]string, len(tags))
+	for i, tag := range tags {
+		hashtags[i] = "#" + strings.TrimPrefix(tag, "#")
+	}
+	data := noteData{Message: message, Tags: strings.Join(hashtags, " ")}
+	if tmpl == "" {
+		return strings.TrimSpace(data.Message + " " + data.Tags), nil
+	}
+
+	t, err := template.New("note").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const authorizeURL = "https://auth.freshbooks.com/service/auth/oauth/authorize"
+
+// oauthFlow holds the secrets of a single authorization code attempt: the
+// state that ties the callback to this attempt and the PKCE code verifier.
+type oauthFlow struct {
+	clientID    string
+	redirectURI string
+	state       string
+	verifier    string
+}
+
+// newOAuthFlow generates a fresh state and PKCE code verifier.
+func newOAuthFlow(clientID, redirectURI string) (*oauthFlow, error) {
+	state, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	return &oauthFlow{
+		clientID:    clientID,
+		redirectURI: redirectURI,
+		state:       state,
+		verifier:    verifier,
+	}, nil
+}
+
+// randomToken returns n random bytes encoded as unpadded base64url.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// challenge returns the S256 PKCE code challenge for the verifier.
+func (f *oauthFlow) challenge() string {
+	sum := sha256.Sum256([]byte(f.verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// authorizeURL returns the URL the user opens to grant access.
+func (f *oauthFlow) authorizeURL() string {
+	q := url.Values{
+		"client_id":             {f.clientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {f.redirectURI},
+		"state":                 {f.state},
+		"code_challenge":        {f.challenge()},
+		"code_challenge_method": {"S256"},
+	}
+	return authorizeURL + "?" + q.Encode()
+}
+
+// verifyCallback checks the query parameters of a redirect back from
+// FreshBooks and returns the authorization code.
+func (f *oauthFlow) verifyCallback(q url.Values) (string, error) {
+	if e := q.Get("error"); e != "" {
+		if desc := q.Get("error_description"); desc != "" {
+			return "", fmt.Errorf("authorization denied: %s (%s)", desc, e)
+		}
+		return "", fmt.Errorf("authorization denied: %s", e)
+	}
+	if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(f.state)) != 1 {
+		return "", fmt.Errorf("state mismatch: the callback did not come from this setup attempt")
+	}
+	code := q.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("no authorization code received")
+	}
+	return code, nil
+}
+
+// parsePasted extracts the authorization code from input pasted by the user:
+// either the full redirect URL, which is verified like a callback, or the
+// bare code.
+func (f *oauthFlow) parsePasted(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("no authorization code received")
+	}
+	if !strings.Contains(input, "?") && !strings.Contains(input, "=") {
+		return input, nil
+	}
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("could not parse pasted URL: %w", err)
+	}
+	q := u.Query()
+	if u.RawQuery == "" {
+		// A bare query string such as "code=...&state=...".
+		if q, err = url.ParseQuery(input); err != nil {
+			return "", fmt.Errorf("could not parse pasted URL: %w", err)
+		}
+	}
+	return f.verifyCallback(q)
+}
+
+// callbackResult is the outcome of the first request to the callback handler.
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// callbackHandler serves the redirect URI. The outcome of the first callback
+// request is sent on results, which must have room for it; later requests are
+// told the attempt is over.
+func (f *oauthFlow) callbackHandler(results chan<- callbackResult) http.Handler {
+	var once sync.Once
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, err := f.verifyCallback(r.URL.Query())
+		first := false
+		once.Do(func() {
+			first = true
+			results <- callbackResult{code, err}
+		})
+
+		switch {
+		case !first:
+			writeCallbackPage(w, http.StatusGone, "Already handled",
+				"This setup attempt has already finished. Run `freshtime setup` again if needed.")
+		case err != nil:
+			writeCallbackPage(w, http.StatusBadRequest, "Authorization failed",
+				err.Error()+". Close this tab and run `freshtime setup` again.")
+		default:
+			writeCallbackPage(w, http.StatusOK, "Done!", "You can close this tab.")
+		}
+	})
+}
+
+func writeCallbackPage(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<html><body><h1>%s</h1><p>%s</p></body></html>", html.EscapeString(title), html.EscapeString(message))
+}
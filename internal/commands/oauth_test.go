@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOAuthFlowAuthorizeURL(t *testing.T) {
+	flow, err := newOAuthFlow("my-client", "https://localhost:8457/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(flow.authorizeURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("state") != flow.state || flow.state == "" {
+		t.Errorf("state = %q, want %q", q.Get("state"), flow.state)
+	}
+	if q.Get("code_challenge_method") != "S256" {
+		t.Errorf("code_challenge_method = %q", q.Get("code_challenge_method"))
+	}
+	sum := sha256.Sum256([]byte(flow.verifier))
+	if want := base64.RawURLEncoding.EncodeToString(sum[:]); q.Get("code_challenge") != want {
+		t.Errorf("code_challenge = %q, want %q", q.Get("code_challenge"), want)
+	}
+	if q.Get("redirect_uri") != "https://localhost:8457/callback" || q.Get("client_id") != "my-client" {
+		t.Errorf("unexpected query %v", q)
+	}
+
+	other, _ := newOAuthFlow("my-client", "https://localhost:8457/callback")
+	if other.state == flow.state || other.verifier == flow.verifier {
+		t.Error("flows share state or verifier")
+	}
+}
+
+func TestOAuthCallbackHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      func(state string) string
+		wantStatus int
+		wantCode   string
+		wantErr    string
+	}{
+		{
+			name:       "valid",
+			query:      func(state string) string { return "code=abc&state=" + state },
+			wantStatus: http.StatusOK,
+			wantCode:   "abc",
+		},
+		{
+			name:       "state mismatch",
+			query:      func(string) string { return "code=abc&state=forged" },
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "state mismatch",
+		},
+		{
+			name:       "missing state",
+			query:      func(string) string { return "code=abc" },
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "state mismatch",
+		},
+		{
+			name:       "missing code",
+			query:      func(state string) string { return "state=" + state },
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "no authorization code",
+		},
+		{
+			name:       "denied",
+			query:      func(state string) string { return "error=access_denied&state=" + state },
+			wantStatus: http.StatusBadRequest,
+			wantErr:    "access_denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow, err := newOAuthFlow("id", "https://localhost:8457/callback")
+			if err != nil {
+				t.Fatal(err)
+			}
+			results := make(chan callbackResult, 1)
+			h := flow.callbackHandler(results)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/callback?"+tt.query(flow.state), nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			res := <-results
+			if tt.wantErr != "" {
+				if res.err == nil || !strings.Contains(res.err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", res.err, tt.wantErr)
+				}
+				if !strings.Contains(rec.Body.String(), "Authorization failed") {
+					t.Errorf("expected error page, got %s", rec.Body.String())
+				}
+				return
+			}
+			if res.err != nil || res.code != tt.wantCode {
+				t.Errorf("got (%q, %v), want %q", res.code, res.err, tt.wantCode)
+			}
+
+			// A replayed callback is not accepted a second time.
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/callback?"+tt.query(flow.state), nil))
+			if rec.Code != http.StatusGone {
+				t.Errorf("replayed callback status = %d, want %d", rec.Code, http.StatusGone)
+			}
+		})
+	}
+}
+
+func TestOAuthFlowParsePasted(t *testing.T) {
+	flow, err := newOAuthFlow("id", "https://localhost:8457/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{"bare code", "  abc123\n", "abc123", ""},
+		{"redirect URL", "https://localhost:8457/callback?code=abc123&state=" + flow.state, "abc123", ""},
+		{"query string", "code=abc123&state=" + flow.state, "abc123", ""},
+		{"forged URL", "https://localhost:8457/callback?code=abc123&state=nope", "", "state mismatch"},
+		{"empty", "\n", "", "no authorization code"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := flow.parsePasted(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got (%q, %v), want %q", got, err, tt.want)
+			}
+		})
+	}
+}
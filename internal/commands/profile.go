@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hev/freshtime/internal/config"
+)
+
+// ProfileCmd returns the profile command for managing named config profiles.
+func ProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage named profiles for different FreshBooks businesses",
+		Long: `Manage named profiles for different FreshBooks businesses.
+
+Create a profile with ` + "`freshtime setup --profile NAME`" + `. Any command can
+use a profile other than the current one via --profile or FRESHTIME_PROFILE.`,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List profiles, marking the current one",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileList()
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "use NAME",
+		Short: "Make NAME the current profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileUse(args[0])
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove NAME",
+		Short: "Delete profile NAME and its credentials",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runProfileRemove(args[0])
+		},
+	})
+	return cmd
+}
+
+// loadProfiles reads the config file, treating a missing file as an error.
+func loadProfiles() (*config.File, error) {
+	f, err := config.LoadFile()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no profiles configured. Run `freshtime setup` to create one")
+	}
+	return f, err
+}
+
+func runProfileList() error {
+	f, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  %-16s%-12s%s\n", "Profile", "Business", "Account")
+	for _, name := range f.Names() {
+		cfg := f.Profiles[name]
+		marker := " "
+		if name == f.Active() {
+			marker = "*"
+		}
+		fmt.Printf("%s %-16s%-12d%s\n", marker, name, cfg.BusinessID, cfg.AccountID)
+	}
+	return nil
+}
+
+func runProfileUse(name string) error {
+	f, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	f.CurrentProfile = name
+	if err := f.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Printf("Now using profile %q.\n", name)
+	return nil
+}
+
+func runProfileRemove(name string) error {
+	f, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	if err := f.Remove(name); err != nil {
+		return fmt.Errorf("failed to remove profile: %w", err)
+	}
+	fmt.Printf("Removed profile %q.\n", name)
+	if f.CurrentProfile != "" {
+		fmt.Printf("Current profile: %s\n", f.CurrentProfile)
+	}
+	return nil
+}
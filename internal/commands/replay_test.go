@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/format"
+	"github.com/hev/freshtime/internal/testutil"
+)
+
+// useReplay points the api package at fixtures in testdata/<name> and
+// writes a throwaway config for the duration of the test.
+func useReplay(t *testing.T, name string) {
+	t.Helper()
+	testutil.SetHome(t)
+	if err := config.Save(&config.Config{AccessToken: "test-token", AccountID: "abc123", BusinessID: 42}); err != nil {
+		t.Fatalf("saving config: %v", err)
+	}
+
+	rep, err := api.NewReplayer("testdata/" + name)
+	if err != nil {
+		t.Fatalf("loading fixtures: %v", err)
+	}
+	origTransport := api.Transport
+	api.Transport = rep
+	t.Cleanup(func() { api.Transport = origTransport })
+}
+
+// captureStdout returns everything fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	runErr := fn()
+	w.Close()
+	os.Stdout = orig
+	out, _ := io.ReadAll(r)
+	return string(out), runErr
+}
+
+func TestWeeklyReplay(t *testing.T) {
+	useReplay(t, "weekly")
+
+	out, err := captureStdout(t, func() error {
+		return runWeekly(context.Background(), "2026-02-11", true)
+	})
+	if err != nil {
+		t.Fatalf("runWeekly: %v", err)
+	}
+
+	var summary format.WeeklySummary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out)
+	}
+	if summary.GrandTotal != 4.5 {
+		t.Errorf("grandTotal = %v, want 4.5", summary.GrandTotal)
+	}
+	acme := findClient(summary.Clients, "Acme Corp")
+	if acme == nil || acme.Total != 3 {
+		t.Errorf("Acme Corp = %+v, want total 3", acme)
+	}
+}
@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/config"
+)
+
+// apiURLEnv overrides the FreshBooks API base URL, e.g. to target `freshtime dev fake-server`.
+const apiURLEnv = "FRESHTIME_API_URL"
+
+// RootCmd returns the freshtime root command with all subcommands attached.
+// Execute runs it and undoes the global state it sets up.
+func RootCmd() *cobra.Command {
+	root, _ := newRootCmd()
+	return root
+}
+
+// newRootCmd returns the root command and a function that releases the
+// --timeout context and restores the api transport and base URL it installs,
+// so each run starts from the same state.
+func newRootCmd() (*cobra.Command, func()) {
+	var (
+		timeout     time.Duration
+		recordDir   string
+		replayDir   string
+		debug       bool
+		debugFormat string
+		profile     string
+		configFile  string
+		accountID   string
+		businessID  string
+
+		cancelTimeout context.CancelFunc = func() {}
+		transport                        = api.Transport
+		baseURL                          = api.BaseURL
+	)
+	cleanup := func() {
+		cancelTimeout()
+		api.Transport = transport
+		api.BaseURL = baseURL
+	}
+
+	root := &cobra.Command{
+		Use:           "freshtime",
+		Short:         "FreshBooks weekly time summary CLI",
+		Version:       "1.0.0",
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if timeout < 0 {
+				return fmt.Errorf("invalid --timeout %s", timeout)
+			}
+			if timeout > 0 {
+				var ctx context.Context
+				ctx, cancelTimeout = context.WithTimeout(cmd.Context(), timeout)
+				cmd.SetContext(ctx)
+			}
+			config.Profile = profile
+			config.ConfigFile = configFile
+			if cmd.Flags().Changed("account-id") {
+				config.Flags["account_id"] = accountID
+			}
+			if cmd.Flags().Changed("business-id") {
+				config.Flags["business_id"] = businessID
+			}
+			if u := os.Getenv(apiURLEnv); u != "" {
+				api.BaseURL = strings.TrimSuffix(u, "/")
+			}
+			if err := installTransport(recordDir, replayDir); err != nil {
+				return err
+			}
+			return installDebug(cmd, debug, debugFormat)
+		},
+	}
+
+	root.PersistentFlags().StringVar(&profile, "profile", "", "Config profile to use (default: the current profile); also FRESHTIME_PROFILE")
+	root.PersistentFlags().StringVar(&configFile, "config", "", "Config file to use; also FRESHTIME_CONFIG (default $XDG_CONFIG_HOME/freshtime/config.json)")
+	root.PersistentFlags().StringVar(&accountID, "account-id", "", "Override the profile's FreshBooks account ID; also FRESHTIME_ACCOUNT_ID")
+	root.PersistentFlags().StringVar(&businessID, "business-id", "", "Override the profile's FreshBooks business ID; also FRESHTIME_BUSINESS_ID")
+	root.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Abort if the command takes longer than this (e.g. 30s, 2m)")
+	root.PersistentFlags().StringVar(&recordDir, "record", "", "Record FreshBooks requests and responses (redacted) to fixture files in this directory")
+	root.PersistentFlags().StringVar(&replayDir, "replay", "", "Serve FreshBooks responses from fixture files in this directory instead of the network")
+	root.MarkFlagsMutuallyExclusive("record", "replay")
+	root.PersistentFlags().BoolVar(&debug, "debug", false, "Log API requests and responses (redacted) to stderr; also FRESHTIME_DEBUG=1")
+	root.PersistentFlags().StringVar(&debugFormat, "debug-format", "text", "Debug log format: text or json; FRESHTIME_DEBUG=json also selects json")
+	root.PersistentFlags().IntVar(&api.DefaultPageConcurrency, "page-concurrency", api.DefaultPageConcurrency, "Number of result pages to fetch in parallel")
+
+	root.AddCommand(SetupCmd())
+	root.AddCommand(WeeklyCmd())
+	root.AddCommand(ClientsCmd())
+	root.AddCommand(InvoiceCmd())
+	root.AddCommand(InitCmd())
+	root.AddCommand(LogCmd())
+	root.AddCommand(StartCmd())
+	root.AddCommand(StopCmd())
+	root.AddCommand(TimerStatusCmd())
+	root.AddCommand(EntriesCmd())
+	root.AddCommand(ProfileCmd())
+	root.AddCommand(AuthCmd())
+	root.AddCommand(ConfigCmd())
+	root.AddCommand(DevCmd())
+
+	return root, cleanup
+}
+
+// installTransport points the api package at a recording or replaying transport.
+func installTransport(recordDir, replayDir string) error {
+	switch {
+	case recordDir != "":
+		rec, err := api.NewRecorder(recordDir, nil)
+		if err != nil {
+			return fmt.Errorf("failed to set up --record: %w", err)
+		}
+		api.Transport = rec
+	case replayDir != "":
+		rep, err := api.NewReplayer(replayDir)
+		if err != nil {
+			return fmt.Errorf("failed to set up --replay: %w", err)
+		}
+		api.Transport = rep
+	}
+	return nil
+}
+
+// debugEnv enables API tracing: "1" or "true" for text output, "json" for JSON.
+const debugEnv = "FRESHTIME_DEBUG"
+
+// installDebug wraps the api transport with request tracing when enabled by
+// --debug or FRESHTIME_DEBUG.
+func installDebug(cmd *cobra.Command, debug bool, format string) error {
+	if env := os.Getenv(debugEnv); env != "" && !cmd.Flags().Changed("debug") {
+		switch strings.ToLower(env) {
+		case "0", "false":
+		case "json":
+			debug = true
+			if !cmd.Flags().Changed("debug-format") {
+				format = "json"
+			}
+		default:
+			debug = true
+		}
+	}
+	if !debug {
+		return nil
+	}
+
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handler slog.Handler
+	switch format {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid --debug-format %q (expected text or json)", format)
+	}
+	api.Transport = &api.DebugTransport{Base: api.Transport, Logger: slog.New(handler)}
+	return nil
+}
+
+// Execute runs the root command with ctx, translating cancellation into
+// user-facing errors.
+func Execute(ctx context.Context) error {
+	root, cleanup := newRootCmd()
+	err := root.ExecuteContext(ctx)
+	cleanup()
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		timeout, _ := root.PersistentFlags().GetDuration("timeout")
+		return fmt.Errorf("timed out after %s (raise --timeout to allow more time)", timeout)
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("interrupted")
+	}
+	var permErr *api.PermissionError
+	if errors.As(err, &permErr) {
+		return fmt.Errorf("%w\nThe FreshBooks app may be missing an OAuth scope for this action; re-run `freshtime setup` after granting it", err)
+	}
+	return err
+}
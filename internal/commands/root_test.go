@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/testutil"
+)
+
+func TestRootCmdRestoresTransport(t *testing.T) {
+	testutil.SetHome(t)
+	t.Setenv(apiURLEnv, "http://127.0.0.1:1")
+	orig, origURL := api.Transport, api.BaseURL
+
+	for i := 0; i < 2; i++ {
+		root, cleanup := newRootCmd()
+		root.SetArgs([]string{"--debug", "--timeout", "1m", "profile", "list"})
+		root.SilenceUsage = true
+		var ctx context.Context
+		captureStdout(t, func() error {
+			cmd, err := root.ExecuteContextC(context.Background())
+			ctx = cmd.Context()
+			return err
+		})
+		if _, ok := api.Transport.(*api.DebugTransport); !ok {
+			t.Fatalf("run %d: --debug did not install a DebugTransport", i)
+		}
+		cleanup()
+		if api.Transport != orig || api.BaseURL != origURL {
+			t.Fatalf("run %d: transport or base URL not restored", i)
+		}
+		if ctx.Err() == nil {
+			t.Errorf("run %d: --timeout context was not cancelled", i)
+		}
+	}
+}
@@ -1,22 +1,22 @@
 package commands
 
 import (
-	"bytes"
+	"bufio"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
-	"encoding/json"
 	"encoding/pem"
 	"fmt"
-	"io"
 	"math/big"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -25,20 +25,152 @@ import (
 	"github.com/hev/freshtime/internal/config"
 )
 
-const redirectURI = "https://localhost:8457/callback"
+// Defaults for the local OAuth callback server.
+const (
+	defaultRedirectURI     = "https://localhost:8457/callback"
+	defaultCallbackTimeout = 5 * time.Minute
+)
+
+// Environment variables configuring the OAuth callback, between flags and
+// the config file in precedence.
+const (
+	redirectURIEnv     = "FRESHTIME_REDIRECT_URI"
+	callbackAddrEnv    = "FRESHTIME_CALLBACK_ADDR"
+	callbackTLSEnv     = "FRESHTIME_CALLBACK_TLS"
+	callbackTimeoutEnv = "FRESHTIME_CALLBACK_TIMEOUT"
+)
 
 // SetupCmd returns the setup command.
 func SetupCmd() *cobra.Command {
-	return &cobra.Command{
+	var (
+		manual     bool
+		token      string
+		businessID int
+		cb         callbackSettings
+		timeout    string
+	)
+
+	cmd := &cobra.Command{
 		Use:   "setup",
 		Short: "Authenticate with FreshBooks via OAuth",
+		Long: `Authenticate with FreshBooks via OAuth and save the token to a profile.
+
+Use the global --profile flag to set up a named profile for another
+business, e.g. ` + "`freshtime setup --profile acme`" + `. When your account
+belongs to several businesses you are asked which one to use.
+
+Without a local browser (over SSH, in a container), use --manual: open the
+link anywhere, then paste back the URL you were redirected to, or just its
+code. For CI service accounts, import an existing access token with --token,
+or --token - to read it from stdin.
+
+The redirect URI must match the one registered for your FreshBooks app. The
+callback server listens on the redirect URI's port on 127.0.0.1 unless
+--callback-addr says otherwise; it only ever binds to a loopback address.
+Each callback option can also be set with an environment variable
+(` + redirectURIEnv + `, ` + callbackAddrEnv + `, ` + callbackTLSEnv + `,
+` + callbackTimeoutEnv + `) or in the "oauth" section of the config file.`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSetup()
+			settings, err := resolveCallbackSettings(cb, timeout)
+			if err != nil {
+				return err
+			}
+			return runSetup(cmd.Context(), manual, token, businessID, settings)
 		},
 	}
+
+	cmd.Flags().BoolVar(&manual, "manual", false, "Paste the redirect URL or code instead of running a local callback server")
+	cmd.Flags().StringVar(&token, "token", "", "Import an existing access token instead of running OAuth (- reads it from stdin)")
+	cmd.Flags().IntVar(&businessID, "business", 0, "Business ID to use when the account belongs to several (skips the prompt)")
+	cmd.Flags().StringVar(&cb.RedirectURI, "redirect-uri", "", "OAuth redirect URI registered for the FreshBooks app (default "+defaultRedirectURI+")")
+	cmd.Flags().StringVar(&cb.Addr, "callback-addr", "", "Loopback address for the callback server (default: 127.0.0.1 and the redirect URI's port)")
+	cmd.Flags().StringVar(&cb.TLS, "callback-tls", "", "Serve the callback over TLS with a self-signed cert: auto, on or off (default auto: on for https redirect URIs)")
+	cmd.Flags().StringVar(&timeout, "callback-timeout", "", "Give up if no authorization arrives within this time (default 5m)")
+	cmd.MarkFlagsMutuallyExclusive("manual", "token")
+
+	return cmd
+}
+
+// callbackSettings configure the local server that receives the OAuth redirect.
+type callbackSettings struct {
+	RedirectURI string
+	Addr        string
+	TLS         string // "auto", "on" or "off"
+	Timeout     time.Duration
+
+	host string // host name of the redirect URI
+	path string // path of the redirect URI
 }
 
-func generateSelfSignedCert() (tls.Certificate, error) {
+// resolveCallbackSettings fills in each setting from flags, then the
+// environment, then the config file, then the defaults, and validates them.
+func resolveCallbackSettings(flags callbackSettings, timeout string) (*callbackSettings, error) {
+	var file config.OAuthSettings
+	if f, err := config.LoadFile(); err == nil {
+		file = f.OAuth
+	}
+	pick := func(flag, env, fromFile, def string) string {
+		for _, v := range []string{flag, os.Getenv(env), fromFile} {
+			if v != "" {
+				return v
+			}
+		}
+		return def
+	}
+
+	s := &callbackSettings{
+		RedirectURI: pick(flags.RedirectURI, redirectURIEnv, file.RedirectURI, defaultRedirectURI),
+		Addr:        pick(flags.Addr, callbackAddrEnv, file.CallbackAddr, ""),
+		TLS:         pick(flags.TLS, callbackTLSEnv, file.CallbackTLS, "auto"),
+		Timeout:     defaultCallbackTimeout,
+	}
+	if t := pick(timeout, callbackTimeoutEnv, file.CallbackTimeout, ""); t != "" {
+		d, err := time.ParseDuration(t)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid callback timeout %q", t)
+		}
+		s.Timeout = d
+	}
+
+	u, err := url.Parse(s.RedirectURI)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid redirect URI %q (expected http(s)://host:port/path)", s.RedirectURI)
+	}
+	s.host = u.Hostname()
+	s.path = u.Path
+	if s.path == "" {
+		s.path = "/"
+	}
+
+	switch s.TLS {
+	case "auto":
+		s.TLS = "off"
+		if u.Scheme == "https" {
+			s.TLS = "on"
+		}
+	case "on", "off":
+	default:
+		return nil, fmt.Errorf("invalid callback TLS mode %q (expected auto, on or off)", s.TLS)
+	}
+
+	if s.Addr == "" {
+		port := u.Port()
+		if port == "" {
+			port = "443"
+			if u.Scheme == "http" {
+				port = "80"
+			}
+		}
+		s.Addr = net.JoinHostPort("127.0.0.1", port)
+	}
+	if err := config.CheckLoopback(s.Addr); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func generateSelfSignedCert(host string) (tls.Certificate, error) {
 	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		return tls.Certificate{}, fmt.Errorf("failed to generate key: %w", err)
@@ -46,14 +178,19 @@ func generateSelfSignedCert() (tls.Certificate, error) {
 
 	template := &x509.Certificate{
 		SerialNumber: big.NewInt(1),
-		Subject:      pkix.Name{CommonName: "localhost"},
+		Subject:      pkix.Name{CommonName: host},
 		NotBefore:    time.Now(),
 		NotAfter:     time.Now().Add(24 * time.Hour),
 		KeyUsage:     x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
-		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
 		DNSNames:     []string{"localhost"},
 	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = append(template.IPAddresses, ip)
+	} else if host != "localhost" {
+		template.DNSNames = append(template.DNSNames, host)
+	}
 
 	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
 	if err != nil {
@@ -70,39 +207,27 @@ func generateSelfSignedCert() (tls.Certificate, error) {
 	return tls.X509KeyPair(certPEM, keyPEM)
 }
 
-func waitForAuthCode() (string, error) {
-	cert, err := generateSelfSignedCert()
-	if err != nil {
-		return "", err
-	}
-
-	codeCh := make(chan string, 1)
+// waitForAuthCode serves the OAuth callback for flow and returns the
+// authorization code once a verified callback arrives.
+func waitForAuthCode(ctx context.Context, flow *oauthFlow, cb *callbackSettings) (string, error) {
+	resultCh := make(chan callbackResult, 1)
 	errCh := make(chan error, 1)
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		if code == "" {
-			errCh <- fmt.Errorf("no authorization code received")
-			http.Error(w, "Error: no code received. Close this tab and try again.", http.StatusBadRequest)
-			return
-		}
-		codeCh <- code
-		w.Header().Set("Content-Type", "text/html")
-		fmt.Fprint(w, "<html><body><h1>Done! You can close this tab.</h1></body></html>")
-	})
-
-	server := &http.Server{
-		Addr:    ":8457",
-		Handler: mux,
-		TLSConfig: &tls.Config{
-			Certificates: []tls.Certificate{cert},
-		},
-	}
+	mux.Handle(cb.path, flow.callbackHandler(resultCh))
+	server := &http.Server{Handler: mux}
 
-	ln, err := tls.Listen("tcp", ":8457", server.TLSConfig)
+	ln, err := net.Listen("tcp", cb.Addr)
 	if err != nil {
-		return "", fmt.Errorf("failed to listen on :8457: %w", err)
+		return "", fmt.Errorf("failed to listen on %s: %w", cb.Addr, err)
+	}
+	if cb.TLS == "on" {
+		cert, err := generateSelfSignedCert(cb.host)
+		if err != nil {
+			ln.Close()
+			return "", err
+		}
+		ln = tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}})
 	}
 
 	go func() {
@@ -111,87 +236,135 @@ func waitForAuthCode() (string, error) {
 		}
 	}()
 
-	defer server.Close()
+	defer func() {
+		// Give the browser a moment to receive the result page.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	timer := time.NewTimer(cb.Timeout)
+	defer timer.Stop()
 
 	select {
-	case code := <-codeCh:
-		return code, nil
+	case res := <-resultCh:
+		return res.code, res.err
 	case err := <-errCh:
 		return "", err
+	case <-timer.C:
+		return "", fmt.Errorf("no authorization received within %s (raise --callback-timeout, or use --manual)", cb.Timeout)
+	case <-ctx.Done():
+		return "", ctx.Err()
 	}
 }
 
-func exchangeCodeForToken(clientID, clientSecret, code string) (accessToken, refreshToken string, err error) {
-	payload, err := json.Marshal(map[string]string{
+func exchangeCodeForToken(ctx context.Context, flow *oauthFlow, clientSecret, code string) (*api.Token, error) {
+	tok, err := api.RequestToken(ctx, map[string]string{
 		"grant_type":    "authorization_code",
-		"client_id":     clientID,
+		"client_id":     flow.clientID,
 		"client_secret": clientSecret,
 		"code":          code,
-		"redirect_uri":  redirectURI,
+		"redirect_uri":  flow.redirectURI,
+		"code_verifier": flow.verifier,
 	})
 	if err != nil {
-		return "", "", err
-	}
-
-	resp, err := http.Post(api.BaseURL+"/auth/oauth/token", "application/json",
-		bytes.NewReader(payload))
-	if err != nil {
-		return "", "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return "", "", fmt.Errorf("token exchange failed (%d): %s", resp.StatusCode, body)
+		return nil, fmt.Errorf("token exchange failed: %w", err)
 	}
+	return tok, nil
+}
 
-	var result struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", "", err
+func runSetup(ctx context.Context, manual bool, token string, businessID int, cb *callbackSettings) error {
+	in := bufio.NewReader(os.Stdin)
+	if token != "" {
+		if token == "-" {
+			line, err := in.ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("failed to read token from stdin: %w", err)
+			}
+			token = strings.TrimSpace(line)
+		}
+		if token == "" {
+			return fmt.Errorf("empty token")
+		}
+		return completeSetup(ctx, in, &api.Token{AccessToken: token}, businessID)
 	}
-	return result.AccessToken, result.RefreshToken, nil
-}
 
-func runSetup() error {
 	clientID := os.Getenv("FRESHBOOKS_CLIENT_ID")
 	clientSecret := os.Getenv("FRESHBOOKS_CLIENT_SECRET")
 	if clientID == "" || clientSecret == "" {
 		return fmt.Errorf("missing FRESHBOOKS_CLIENT_ID or FRESHBOOKS_CLIENT_SECRET environment variables")
 	}
 
-	authURL := fmt.Sprintf("https://auth.freshbooks.com/service/auth/oauth/authorize?client_id=%s&response_type=code&redirect_uri=%s",
-		url.QueryEscape(clientID), url.QueryEscape(redirectURI))
+	flow, err := newOAuthFlow(clientID, cb.RedirectURI)
+	if err != nil {
+		return err
+	}
 
 	fmt.Print("Open this link to authorize freshtime:\n\n")
-	fmt.Printf("  %s\n\n", authURL)
-	fmt.Println("Waiting for authorization...")
+	fmt.Printf("  %s\n\n", flow.authorizeURL())
 
-	code, err := waitForAuthCode()
+	var code string
+	if manual {
+		code, err = readPastedCode(in, flow)
+	} else {
+		fmt.Println("Waiting for authorization...")
+		code, err = waitForAuthCode(ctx, flow, cb)
+	}
 	if err != nil {
 		return fmt.Errorf("authorization failed: %w", err)
 	}
 
 	fmt.Println("Exchanging code for token...")
-	accessToken, refreshToken, err := exchangeCodeForToken(clientID, clientSecret, code)
+	tok, err := exchangeCodeForToken(ctx, flow, clientSecret, code)
 	if err != nil {
 		return err
 	}
+	return completeSetup(ctx, in, tok, businessID)
+}
+
+// readPastedCode prompts for the redirect URL (or bare code) after the user
+// authorized freshtime in a browser elsewhere.
+func readPastedCode(in *bufio.Reader, flow *oauthFlow) (string, error) {
+	fmt.Println("After authorizing, your browser is sent to a page that may fail to load.")
+	fmt.Print("Paste its full URL (or just the code) here: ")
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return flow.parsePasted(line)
+}
 
+// completeSetup verifies tok, picks the business and saves the profile.
+func completeSetup(ctx context.Context, in *bufio.Reader, tok *api.Token, businessID int) error {
 	fmt.Println("Verifying token...")
-	httpClient := api.NewHttpClient(accessToken)
-	identity, err := api.GetIdentity(httpClient)
+	httpClient := api.NewHttpClient(tok.AccessToken)
+	businesses, err := api.ListBusinessesContext(ctx, httpClient)
 	if err != nil {
 		return fmt.Errorf("invalid token: %w", err)
 	}
+	business, err := pickBusiness(in, businesses, businessID)
+	if err != nil {
+		return err
+	}
 
 	cfg := &config.Config{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		AccountID:    identity.AccountID,
-		BusinessID:   identity.BusinessID,
+		Name:           config.Profile,
+		AccessToken:    tok.AccessToken,
+		RefreshToken:   tok.RefreshToken,
+		TokenExpiresAt: tok.Expiry,
+		TokenCreatedAt: tok.CreatedAt,
+		TokenScope:     tok.Scope,
+		AccountID:      business.AccountID,
+		BusinessID:     business.ID,
+	}
+	// Keep per-client settings when re-authenticating an existing profile
+	// for the same business.
+	if existing, err := config.Load(); err == nil && existing.BusinessID == business.ID {
+		cfg.Name = existing.Name
+		cfg.ClientRates = existing.ClientRates
+		cfg.DefaultCurrency = existing.DefaultCurrency
+		cfg.Rounding = existing.Rounding
+		cfg.ClientRounding = existing.ClientRounding
 	}
 	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
@@ -199,8 +372,39 @@ func runSetup() error {
 
 	fmt.Println()
 	fmt.Println("Setup complete.")
-	fmt.Printf("  Account:  %s\n", identity.AccountID)
-	fmt.Printf("  Business: %d\n", identity.BusinessID)
+	fmt.Printf("  Profile:  %s\n", cfg.Name)
+	fmt.Printf("  Account:  %s\n", business.AccountID)
+	fmt.Printf("  Business: %s (%d)\n", business.Name, business.ID)
 	fmt.Printf("  Config:   %s\n", config.Path())
 	return nil
 }
+
+// pickBusiness returns the business with ID id, or asks which one to use
+// when id is 0 and the user belongs to more than one.
+func pickBusiness(reader *bufio.Reader, businesses []api.Business, id int) (api.Business, error) {
+	if id != 0 {
+		for _, b := range businesses {
+			if b.ID == id {
+				return b, nil
+			}
+		}
+		return api.Business{}, fmt.Errorf("this account has no access to business %d", id)
+	}
+	if len(businesses) == 1 {
+		return businesses[0], nil
+	}
+	names := make(map[int]string, len(businesses))
+	for _, b := range businesses {
+		names[b.ID] = b.Name
+	}
+	id, err := pickFromMap(reader, "Business", names)
+	if err != nil {
+		return api.Business{}, err
+	}
+	for _, b := range businesses {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return api.Business{}, fmt.Errorf("business %d not found", id)
+}
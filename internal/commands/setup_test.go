@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hev/freshtime/internal/api"
+	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/fakefb"
+	"github.com/hev/freshtime/internal/testutil"
+)
+
+func TestSetupTokenImport(t *testing.T) {
+	fake := fakefb.New()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	origBase := api.BaseURL
+	api.BaseURL = srv.URL
+	t.Cleanup(func() { api.BaseURL = origBase })
+	testutil.SetHome(t)
+
+	if _, err := captureStdout(t, func() error {
+		return runSetup(context.Background(), false, "ci-token", 0, nil)
+	}); err != nil {
+		t.Fatalf("runSetup: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AccessToken != "ci-token" || cfg.AccountID != fake.AccountID || cfg.BusinessID != fake.BusinessID {
+		t.Errorf("saved config = %+v", cfg)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runSetup(context.Background(), false, "ci-token", 999, nil)
+	}); err == nil {
+		t.Error("expected error for a business the account cannot access")
+	}
+}
+
+func TestSetupKeepsProfileSettings(t *testing.T) {
+	fake := useFake(t)
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ClientRates = map[string]string{"12": "150"}
+	cfg.DefaultCurrency = "EUR"
+	cfg.Rounding = &config.Rounding{Increment: "15m", Mode: "up"}
+	cfg.ClientRounding = map[string]*config.Rounding{"12": {Increment: "6m", Minimum: "15m"}}
+	if err := config.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.Token = "new-token"
+	if _, err := captureStdout(t, func() error {
+		return runSetup(context.Background(), false, "new-token", fake.BusinessID, nil)
+	}); err != nil {
+		t.Fatalf("runSetup: %v", err)
+	}
+
+	cfg, err = config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AccessToken != "new-token" {
+		t.Errorf("access token = %q, want new-token", cfg.AccessToken)
+	}
+	if cfg.ClientRates["12"] != "150" || cfg.DefaultCurrency != "EUR" {
+		t.Errorf("rates or currency lost: %+v", cfg)
+	}
+	if r := cfg.Rounding; r == nil || r.Increment != "15m" || r.Mode != "up" {
+		t.Errorf("rounding = %+v, want 15m up", r)
+	}
+	if r := cfg.ClientRounding["12"]; r == nil || r.Increment != "6m" || r.Minimum != "15m" {
+		t.Errorf("client_rounding.12 = %+v, want 6m with a 15m minimum", r)
+	}
+}
+
+func TestResolveCallbackSettings(t *testing.T) {
+	testutil.SetHome(t)
+
+	s, err := resolveCallbackSettings(callbackSettings{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.RedirectURI != defaultRedirectURI || s.Addr != "127.0.0.1:8457" || s.TLS != "on" || s.Timeout != defaultCallbackTimeout || s.path != "/callback" {
+		t.Errorf("defaults = %+v", s)
+	}
+
+	// The config file is overridden by the environment, which is overridden by flags.
+	f, _ := config.LoadFile()
+	f.OAuth = config.OAuthSettings{RedirectURI: "http://localhost:9000/cb", CallbackTimeout: "1m"}
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = resolveCallbackSettings(callbackSettings{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Addr != "127.0.0.1:9000" || s.TLS != "off" || s.Timeout != time.Minute || s.path != "/cb" {
+		t.Errorf("from config = %+v", s)
+	}
+
+	t.Setenv(callbackAddrEnv, "[::1]:9100")
+	s, err = resolveCallbackSettings(callbackSettings{TLS: "on"}, "30s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Addr != "[::1]:9100" || s.TLS != "on" || s.Timeout != 30*time.Second {
+		t.Errorf("from env and flags = %+v", s)
+	}
+
+	for _, bad := range []callbackSettings{
+		{Addr: "0.0.0.0:8457"},
+		{Addr: ":8457"},
+		{Addr: "192.168.1.5:8457"},
+		{TLS: "maybe"},
+		{RedirectURI: "ftp://localhost/cb"},
+	} {
+		if _, err := resolveCallbackSettings(bad, ""); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+}
+
+func TestWaitForAuthCode(t *testing.T) {
+	testutil.SetHome(t)
+	// Find a free loopback port for the callback server.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	cb, err := resolveCallbackSettings(callbackSettings{RedirectURI: "http://" + addr + "/callback"}, "5s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flow, err := newOAuthFlow("id", cb.RedirectURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		// Retry until the server is listening.
+		for range 50 {
+			resp, err := http.Get(cb.RedirectURI + "?code=abc&state=" + flow.state)
+			if err == nil {
+				resp.Body.Close()
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+	code, err := waitForAuthCode(context.Background(), flow, cb)
+	if err != nil || code != "abc" {
+		t.Fatalf("got (%q, %v), want abc", code, err)
+	}
+
+	cb.Timeout = 50 * time.Millisecond
+	if _, err := waitForAuthCode(context.Background(), flow, cb); err == nil || !strings.Contains(err.Error(), "no authorization received") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
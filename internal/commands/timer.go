@@ -1,8 +1,11 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
@@ -12,6 +15,7 @@ import (
 
 	"github.com/hev/freshtime/internal/api"
 	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/duration"
 )
 
 // TimerState persists a running timer.
@@ -22,15 +26,31 @@ type TimerState struct {
 	ProjectID int       `json:"project_id,omitempty"`
 	ServiceID int       `json:"service_id,omitempty"`
 	Billable  bool      `json:"billable"`
+	Profile   string    `json:"profile,omitempty"`
+
+	// Project settings and the client's rounding rule captured at start,
+	// so stop behaves the same from any directory.
+	NoteTemplate string           `json:"note_template,omitempty"`
+	Tags         []string         `json:"tags,omitempty"`
+	Rounding     *config.Rounding `json:"rounding,omitempty"`
 }
 
+// timerPath returns where the running timer is kept, in the state
+// directory.
 func timerPath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "freshtime", "timer.json")
+	return filepath.Join(config.StateHome(), "timer.json")
+}
+
+// legacyTimerPath returns where versions before XDG support kept the timer.
+func legacyTimerPath() string {
+	return filepath.Join(config.LegacyDir(), "timer.json")
 }
 
 func loadTimer() (*TimerState, error) {
 	data, err := os.ReadFile(timerPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = os.ReadFile(legacyTimerPath())
+	}
 	if err != nil {
 		return nil, fmt.Errorf("no timer running")
 	}
@@ -47,53 +67,62 @@ func saveTimer(ts *TimerState) error {
 		return err
 	}
 	data = append(data, '\n')
+	if err := os.MkdirAll(config.StateHome(), 0o755); err != nil {
+		return err
+	}
 	return os.WriteFile(timerPath(), data, 0o644)
 }
 
+// clearTimer removes the running timer, wherever it was saved.
 func clearTimer() error {
-	return os.Remove(timerPath())
+	for _, path := range []string{timerPath(), legacyTimerPath()} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
 }
 
 // StartCmd returns the start command.
 func StartCmd() *cobra.Command {
 	var (
-		message    string
-		client     int
-		project    int
-		service    int
-		noBillable bool
+		message string
+		opts    entryOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start a time tracking timer",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runStart(message, client, project, service, noBillable)
+			return runStart(message, &opts)
 		},
 	}
 
 	cmd.Flags().StringVarP(&message, "message", "m", "", "Note for the time entry")
-	cmd.Flags().IntVar(&client, "client", 0, "Client ID (overrides .freshtime.json)")
-	cmd.Flags().IntVar(&project, "project", 0, "Project ID (overrides .freshtime.json)")
-	cmd.Flags().IntVar(&service, "service", 0, "Service ID (overrides .freshtime.json)")
-	cmd.Flags().BoolVar(&noBillable, "no-billable", false, "Mark as non-billable")
+	opts.addFlags(cmd)
 
 	return cmd
 }
 
 // StopCmd returns the stop command.
 func StopCmd() *cobra.Command {
-	var message string
+	var (
+		message string
+		length  string
+		noRound bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the running timer and log the time entry",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runStop(message)
+			return runStop(cmd.Context(), message, length, noRound)
 		},
 	}
 
 	cmd.Flags().StringVarP(&message, "message", "m", "", "Override the note set at start")
+	cmd.Flags().StringVarP(&length, "duration", "d", "", "Log this duration instead of the elapsed time (e.g. 45m, 1:30)")
+	cmd.Flags().BoolVar(&noRound, "no-round", false, "Log the elapsed time as is, ignoring rounding rules")
 
 	return cmd
 }
@@ -109,7 +138,7 @@ func TimerStatusCmd() *cobra.Command {
 	}
 }
 
-func runStart(message string, clientID, projectID, serviceID int, noBillable bool) error {
+func runStart(message string, opts *entryOptions) error {
 	// Check for existing timer
 	if existing, _ := loadTimer(); existing != nil {
 		elapsed := time.Since(existing.StartedAt)
@@ -117,75 +146,105 @@ func runStart(message string, clientID, projectID, serviceID int, noBillable boo
 			formatElapsed(elapsed), existing.Note)
 	}
 
-	// Load project config for defaults
-	pc, _ := config.LoadProjectConfigFromCwd()
-	if pc != nil {
-		if clientID == 0 {
-			clientID = pc.ClientID
-		}
-		if projectID == 0 {
-			projectID = pc.ProjectID
-		}
-		if serviceID == 0 {
-			serviceID = pc.ServiceID
-		}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
 	}
 
-	if clientID == 0 {
-		return fmt.Errorf("no client specified. Use --client or run `freshtime init` to create .freshtime.json")
+	// Load project config for defaults
+	pc, err := config.LoadProjectDefaults()
+	if err != nil {
+		return err
+	}
+	billable, err := opts.applyProject(pc)
+	if err != nil {
+		return err
+	}
+	note, err := renderNote(pc.NoteTemplate, message, pc.Tags)
+	if err != nil {
+		return fmt.Errorf("invalid note_template: %w", err)
+	}
+	// Check the rounding rule now rather than when the timer is stopped.
+	rounding, roundingSource := cfg.RoundingFor(opts.clientID, pc)
+	if _, err := rounding.Rule(); err != nil {
+		return fmt.Errorf("invalid rounding in %s: %w", roundingSource, err)
 	}
 
 	ts := &TimerState{
-		StartedAt: time.Now(),
-		Note:      message,
-		ClientID:  clientID,
-		ProjectID: projectID,
-		ServiceID: serviceID,
-		Billable:  !noBillable,
+		Profile:      cfg.Name,
+		StartedAt:    time.Now(),
+		Note:         note,
+		ClientID:     opts.clientID,
+		ProjectID:    opts.projectID,
+		ServiceID:    opts.serviceID,
+		Billable:     billable,
+		NoteTemplate: pc.NoteTemplate,
+		Tags:         pc.Tags,
+		Rounding:     rounding,
 	}
 	if err := saveTimer(ts); err != nil {
 		return fmt.Errorf("failed to save timer: %w", err)
 	}
 
 	fmt.Printf("Timer started")
-	if message != "" {
-		fmt.Printf(": %s", message)
+	if note != "" {
+		fmt.Printf(": %s", note)
 	}
 	fmt.Println()
 	return nil
 }
 
-func runStop(messageOverride string) error {
+// runStop logs the running timer. A non-empty length replaces the elapsed
+// time, for when the timer was left running.
+func runStop(ctx context.Context, messageOverride, length string, noRound bool) error {
 	ts, err := loadTimer()
 	if err != nil {
 		return err
 	}
 
 	elapsed := time.Since(ts.StartedAt)
+	if length != "" {
+		if elapsed, err = duration.Parse(length); err != nil {
+			return err
+		}
+	}
 	seconds := int(math.Round(elapsed.Seconds()))
 	if seconds < 60 {
 		seconds = 60 // minimum 1 minute
 	}
 
+	logged, err := roundDuration(seconds, ts.Rounding, noRound)
+	if err != nil {
+		return err
+	}
+
 	note := ts.Note
 	if messageOverride != "" {
-		note = messageOverride
+		if note, err = renderNote(ts.NoteTemplate, messageOverride, ts.Tags); err != nil {
+			return fmt.Errorf("invalid note_template: %w", err)
+		}
 	}
 
+	// Log to the business the timer was started for, unless a profile was
+	// chosen explicitly.
+	if config.Profile == "" && os.Getenv(config.ProfileEnv) == "" {
+		config.Profile = ts.Profile
+	}
 	cfg, err := config.Load()
 	if err != nil {
 		return err
 	}
 
 	http := api.NewClient(cfg)
-	entry, err := api.CreateTimeEntry(http, cfg.BusinessID, api.CreateTimeEntryRequest{
-		ClientID:  ts.ClientID,
-		ProjectID: ts.ProjectID,
-		ServiceID: ts.ServiceID,
-		Duration:  seconds,
-		Note:      note,
-		Billable:  ts.Billable,
-		StartedAt: ts.StartedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	entry, err := api.CreateTimeEntryContext(ctx, http, cfg.BusinessID, api.CreateTimeEntryRequest{
+		ClientID:       ts.ClientID,
+		ProjectID:      ts.ProjectID,
+		ServiceID:      ts.ServiceID,
+		Duration:       logged,
+		Note:           note,
+		Billable:       ts.Billable,
+		StartedAt:      ts.StartedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		LocalStartedAt: ts.StartedAt.Local().Format("2006-01-02T15:04:05"),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create time entry: %w", err)
@@ -195,8 +254,8 @@ func runStop(messageOverride string) error {
 		fmt.Fprintf(os.Stderr, "warning: failed to clear timer state: %v\n", err)
 	}
 
-	hours := float64(seconds) / 3600
-	fmt.Printf("Stopped. Logged %.2fh: %s (entry #%d)\n", hours, note, entry.ID)
+	hours := float64(logged) / 3600
+	fmt.Printf("Stopped. Logged %.2fh%s: %s (entry #%d)\n", hours, roundedFrom(seconds, logged), note, entry.ID)
 	return nil
 }
 
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/hev/freshtime/internal/config"
+	"github.com/hev/freshtime/internal/fakefb"
+)
+
+func TestStopWithDuration(t *testing.T) {
+	fake := useFake(t)
+	ctx := context.Background()
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+
+	if _, err := captureStdout(t, func() error {
+		return runStart("Forgot to stop", &entryOptions{clientID: acme})
+	}); err != nil {
+		t.Fatalf("runStart: %v", err)
+	}
+
+	// A bad duration leaves the timer running.
+	if err := runStop(ctx, "", "0m", false); err == nil {
+		t.Fatal("expected an error for a zero duration")
+	}
+	if ts, err := loadTimer(); err != nil || ts == nil {
+		t.Fatalf("expected the timer to still be running, got %v", err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runStop(ctx, "", "1:15", false)
+	}); err != nil {
+		t.Fatalf("runStop: %v", err)
+	}
+	entries := fake.TimeEntries()
+	if len(entries) != 1 || entries[0].Duration != 4500 || entries[0].Note != "Forgot to stop" {
+		t.Errorf("logged entries = %+v", entries)
+	}
+	if _, err := loadTimer(); err == nil {
+		t.Error("expected the timer to be cleared")
+	}
+}
+
+func TestStartFailsOnBrokenConfig(t *testing.T) {
+	fake := useFake(t)
+	acme := fake.AddClient(fakefb.Client{Organization: "Acme Corp"})
+	if err := os.WriteFile(config.Path(), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runStart("Review", &entryOptions{clientID: acme})
+	}); err == nil {
+		t.Fatal("expected an error for a broken config")
+	}
+	if _, err := loadTimer(); err == nil {
+		t.Error("timer started without the config's rounding rule")
+	}
+}
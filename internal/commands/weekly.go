@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sort"
@@ -23,7 +24,7 @@ func WeeklyCmd() *cobra.Command {
 		Use:   "weekly",
 		Short: "Show weekly time summary grouped by client",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runWeekly(weekOf, jsonOutput)
+			return runWeekly(cmd.Context(), weekOf, jsonOutput)
 		},
 	}
 
@@ -122,7 +123,7 @@ func buildSummary(entries []api.TimeEntry, clientNames map[int]string, weekStart
 	}
 }
 
-func runWeekly(weekOf string, jsonOutput bool) error {
+func runWeekly(ctx context.Context, weekOf string, jsonOutput bool) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return err
@@ -139,12 +140,12 @@ func runWeekly(weekOf string, jsonOutput bool) error {
 	}
 	weekStart, weekEnd := getWeekRange(ref)
 
-	entries, err := api.ListTimeEntries(http, cfg.BusinessID, weekStart, weekEnd)
+	entries, err := api.ListTimeEntriesContext(ctx, http, cfg.BusinessID, weekStart, weekEnd)
 	if err != nil {
 		return err
 	}
 
-	clientNames, err := api.ListClients(http, cfg.AccountID)
+	clientNames, err := api.ListClientsContext(ctx, http, cfg.AccountID)
 	if err != nil {
 		return err
 	}
@@ -2,9 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 )
 
 var (
@@ -13,48 +17,388 @@ var (
 	Date    = "unknown"
 )
 
-// Config holds the freshtime configuration.
+// DefaultProfile is the profile name used when none has been chosen. A
+// config file from before profiles existed is read as this profile.
+const DefaultProfile = "default"
+
+// Profile selects the profile that Load and Save operate on. When empty, the
+// file's current profile is used. It is set from --profile or FRESHTIME_PROFILE.
+var Profile string
+
+// Config holds the freshtime configuration for a single profile.
 type Config struct {
-	AccessToken     string            `json:"access_token"`
+	// Name is the profile this config was loaded from or will be saved to.
+	Name string `json:"-"`
+
+	AccessToken     string            `json:"access_token,omitempty"`
 	RefreshToken    string            `json:"refresh_token,omitempty"`
+	TokenExpiresAt  time.Time         `json:"token_expires_at,omitzero"`
+	TokenCreatedAt  time.Time         `json:"token_created_at,omitzero"`
+	TokenScope      string            `json:"token_scope,omitempty"`
 	AccountID       string            `json:"account_id"`
 	BusinessID      int               `json:"business_id"`
 	ClientRates     map[string]string `json:"client_rates,omitempty"`
 	DefaultCurrency string            `json:"default_currency,omitempty"`
+
+	// Rounding applies to time logged and invoiced for every client;
+	// ClientRounding, keyed by client ID, replaces it for one client.
+	Rounding       *Rounding            `json:"rounding,omitempty"`
+	ClientRounding map[string]*Rounding `json:"client_rounding,omitempty"`
+
+	// sources records which keys were overridden by flags or the
+	// environment, and stored holds the values they replaced.
+	sources map[string]string
+	stored  *Config
 }
 
+// ConfigFile, if set, is the config file to use instead of the default. It
+// is set from --config and takes precedence over FRESHTIME_CONFIG.
+var ConfigFile string
+
+// ConfigEnv names an alternate config file.
+const ConfigEnv = "FRESHTIME_CONFIG"
+
+// configDir returns the directory holding the config file and credential stores.
 func configDir() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "freshtime")
+	return filepath.Dir(Path())
 }
 
-// Path returns the path to the config file.
+// Path returns the path to the config file: --config, then
+// FRESHTIME_CONFIG, then config.json in ConfigHome.
 func Path() string {
-	return filepath.Join(configDir(), "config.json")
+	if ConfigFile != "" {
+		return ConfigFile
+	}
+	if env := os.Getenv(ConfigEnv); env != "" {
+		return env
+	}
+	return filepath.Join(ConfigHome(), "config.json")
 }
 
-// Load reads and parses the config file.
-func Load() (*Config, error) {
-	data, err := os.ReadFile(Path())
+// legacyFiles are the files older versions kept in LegacyDir that belong in
+// ConfigHome. config.json comes last so an interrupted move is redone.
+var legacyFiles = []string{"credentials.json", "credentials.enc", "config.json"}
+
+// moveLegacyConfig moves a config left in LegacyDir by an older version,
+// and the credential stores next to it, into ConfigHome when no config
+// exists there yet. The old config.json is kept as config.json.bak. It does
+// nothing when --config or FRESHTIME_CONFIG names the file.
+func moveLegacyConfig() error {
+	if ConfigFile != "" || os.Getenv(ConfigEnv) != "" {
+		return nil
+	}
+	dir, legacy := ConfigHome(), LegacyDir()
+	if dir == legacy {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config.json")); !errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "config.json")); err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, name := range legacyFiles {
+		data, err := os.ReadFile(filepath.Join(legacy, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(dir, name), data, 0o600); err != nil {
+			return err
+		}
+	}
+	for _, name := range legacyFiles {
+		old := filepath.Join(legacy, name)
+		var err error
+		if name == "config.json" {
+			err = os.Rename(old, old+".bak")
+		} else {
+			err = os.Remove(old)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// File is the on-disk layout of the config file: a set of named profiles,
+// the one used by default, and where their tokens are kept.
+type File struct {
+	SchemaVersion     int                `json:"schema_version"`
+	CurrentProfile    string             `json:"current_profile,omitempty"`
+	CredentialStore   string             `json:"credential_store,omitempty"` // StoreInline when empty
+	CredentialKeyFile string             `json:"credential_key_file,omitempty"`
+	OAuth             OAuthSettings      `json:"oauth,omitzero"`
+	Profiles          map[string]*Config `json:"profiles"`
+}
+
+// OAuthSettings configure the local callback used by `freshtime setup`. They
+// belong to the FreshBooks app rather than a business, so all profiles share
+// them. Empty fields use the defaults.
+type OAuthSettings struct {
+	RedirectURI     string `json:"redirect_uri,omitempty"`
+	CallbackAddr    string `json:"callback_addr,omitempty"`
+	CallbackTLS     string `json:"callback_tls,omitempty"`     // auto, on or off
+	CallbackTimeout string `json:"callback_timeout,omitempty"` // e.g. "5m"
+}
+
+// LoadFile reads the config file with all of its profiles. A missing file
+// yields an empty File and an error satisfying errors.Is(err, fs.ErrNotExist).
+// A file with an older schema version is backed up next to itself and
+// rewritten in the current layout. A config in LegacyDir is first moved
+// into ConfigHome.
+func LoadFile() (*File, error) {
+	if err := moveLegacyConfig(); err != nil {
+		return nil, fmt.Errorf("failed to move config from %s to %s: %w", LegacyDir(), ConfigHome(), err)
+	}
+	path := Path()
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("config not found. Run `freshtime setup` to configure your token")
+		return &File{SchemaVersion: CurrentSchemaVersion, Profiles: make(map[string]*Config)}, err
+	}
+	f, from, err := parseFile(data)
+	if err != nil {
+		return nil, err
 	}
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("invalid config file: %w", err)
+	if from < CurrentSchemaVersion {
+		backup := fmt.Sprintf("%s.v%d.bak", path, from)
+		if err := writeFileAtomic(backup, data, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to back up config before migrating it: %w", err)
+		}
+		if err := f.Save(); err != nil {
+			return nil, fmt.Errorf("failed to migrate config (backup in %s): %w", backup, err)
+		}
 	}
-	return &cfg, nil
+	return f, nil
 }
 
-// Save writes the config to disk.
-func Save(cfg *Config) error {
+// ParseFile decodes the contents of a config file of any schema version,
+// upgrading it to the current layout in memory.
+func ParseFile(data []byte) (*File, error) {
+	f, _, err := parseFile(data)
+	return f, err
+}
+
+// parseFile is ParseFile, also returning the schema version data had.
+func parseFile(data []byte) (*File, int, error) {
+	data, from, err := migrate(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	f := &File{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, 0, fmt.Errorf("invalid config file: %w", err)
+	}
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]*Config)
+	}
+	for name, cfg := range f.Profiles {
+		if cfg == nil {
+			cfg = &Config{}
+			f.Profiles[name] = cfg
+		}
+		cfg.Name = name
+	}
+	return f, from, nil
+}
+
+// Active returns the name of the profile in use: the one selected by
+// --profile, FRESHTIME_PROFILE or the project file, falling back to the
+// file's current profile and then DefaultProfile.
+func (f *File) Active() string {
+	name, _ := f.activeSource()
+	return name
+}
+
+// Names returns the profile names in sorted order.
+func (f *File) Names() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Store returns the credential store configured for f, or nil when tokens
+// are kept inline.
+func (f *File) Store() (CredentialStore, error) {
+	return NewCredentialStore(f.CredentialStore, f.CredentialKeyFile)
+}
+
+// Save writes f to disk, replacing it atomically so a concurrent reader never
+// sees a partially written config. Tokens are only written when they are
+// kept inline; the file is readable by the owner alone either way.
+func (f *File) Save() error {
 	if err := os.MkdirAll(configDir(), 0o755); err != nil {
 		return err
 	}
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	out := *f
+	out.SchemaVersion = CurrentSchemaVersion
+	if f.CredentialStore != "" && f.CredentialStore != StoreInline {
+		out.Profiles = make(map[string]*Config, len(f.Profiles))
+		for name, cfg := range f.Profiles {
+			c := *cfg
+			c.setCredentials(nil)
+			out.Profiles[name] = &c
+		}
+	}
+	data, err := json.MarshalIndent(&out, "", "  ")
 	if err != nil {
 		return err
 	}
 	data = append(data, '\n')
-	return os.WriteFile(Path(), data, 0o644)
+	return writeFileAtomic(Path(), data, 0o600)
+}
+
+// Remove deletes profile name and its stored credentials. If it was the
+// current profile, the first remaining profile becomes current.
+func (f *File) Remove(name string) error {
+	store, err := f.Store()
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		if err := store.Delete(name); err != nil {
+			return fmt.Errorf("failed to delete credentials: %w", err)
+		}
+	}
+	delete(f.Profiles, name)
+	if f.CurrentProfile == name {
+		f.CurrentProfile = ""
+		if names := f.Names(); len(names) > 0 {
+			f.CurrentProfile = names[0]
+		}
+	}
+	return f.Save()
+}
+
+// Credentials returns the tokens held in c.
+func (c *Config) Credentials() *Credentials {
+	return &Credentials{
+		AccessToken:  c.AccessToken,
+		RefreshToken: c.RefreshToken,
+		ExpiresAt:    c.TokenExpiresAt,
+		CreatedAt:    c.TokenCreatedAt,
+		Scope:        c.TokenScope,
+	}
+}
+
+// setCredentials replaces the tokens in c; nil clears them.
+func (c *Config) setCredentials(creds *Credentials) {
+	if creds == nil {
+		creds = &Credentials{}
+	}
+	c.AccessToken = creds.AccessToken
+	c.RefreshToken = creds.RefreshToken
+	c.TokenExpiresAt = creds.ExpiresAt
+	c.TokenCreatedAt = creds.CreatedAt
+	c.TokenScope = creds.Scope
+}
+
+// Load reads the active profile from the config file and applies overrides
+// from flags and the environment. When the access token is overridden, the
+// config file and profile need not exist.
+func Load() (*Config, error) {
+	f, err := LoadFile()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	missing := err != nil
+	name := f.Active()
+	cfg, ok := f.Profiles[name]
+	switch {
+	case ok:
+	case tokenOverridden():
+		cfg = &Config{Name: name}
+	case missing:
+		return nil, fmt.Errorf("config not found. Run `freshtime setup` to configure your token")
+	default:
+		return nil, fmt.Errorf("profile %q not found. Run `freshtime setup --profile %s` to create it", name, name)
+	}
+
+	store, err := f.Store()
+	if err != nil {
+		return nil, err
+	}
+	if store != nil && ok {
+		creds, err := store.Load(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load credentials: %w", err)
+		}
+		cfg.setCredentials(creds)
+	}
+	if err := cfg.applyOverrides(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Save writes cfg into its profile (cfg.Name, or the active profile when
+// unnamed), leaving other profiles untouched. Tokens go to the configured
+// credential store; a new config file uses StoreFile. The first profile
+// saved becomes the current one. Values that came from flags or the
+// environment are not written.
+func Save(cfg *Config) error {
+	f, err := LoadFile()
+	if errors.Is(err, fs.ErrNotExist) {
+		f.CredentialStore = StoreFile
+	} else if err != nil {
+		return err
+	}
+	if cfg.Name == "" {
+		cfg.Name = f.Active()
+	}
+	out := cfg.persistable()
+
+	store, err := f.Store()
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		if out.AccessToken == "" && out.RefreshToken == "" {
+			err = store.Delete(out.Name)
+		} else {
+			err = store.Save(out.Name, out.Credentials())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save credentials: %w", err)
+		}
+	}
+	f.Profiles[out.Name] = out
+	if f.CurrentProfile == "" {
+		f.CurrentProfile = out.Name
+	}
+	return f.Save()
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
@@ -1,11 +1,16 @@
 package config
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/hev/freshtime/internal/testutil"
 )
 
 func TestPath(t *testing.T) {
+	testutil.SetHome(t)
 	p := Path()
 	if filepath.Base(p) != "config.json" {
 		t.Errorf("expected config.json, got %s", filepath.Base(p))
@@ -17,8 +22,7 @@ func TestPath(t *testing.T) {
 
 func TestSaveAndLoad(t *testing.T) {
 	// Use a temp dir to avoid touching real config
-	tmpDir := t.TempDir()
-	t.Setenv("HOME", tmpDir)
+	testutil.SetHome(t)
 
 	cfg := &Config{
 		AccessToken:     "test-token",
@@ -59,11 +63,167 @@ func TestSaveAndLoad(t *testing.T) {
 }
 
 func TestLoadMissingFile(t *testing.T) {
-	tmpDir := t.TempDir()
-	t.Setenv("HOME", tmpDir)
+	testutil.SetHome(t)
 
 	_, err := Load()
 	if err == nil {
 		t.Error("expected error for missing config, got nil")
 	}
 }
+
+func TestLoadLegacyFlatFile(t *testing.T) {
+	testutil.SetHome(t)
+
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"access_token": "old-token", "account_id": "abc", "business_id": 7}`
+	if err := os.WriteFile(Path(), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Name != DefaultProfile || cfg.AccessToken != "old-token" || cfg.BusinessID != 7 {
+		t.Errorf("got %+v, want default profile with legacy values", cfg)
+	}
+
+	// Loading migrated the file to the profiles layout and kept a backup.
+	backup, err := os.ReadFile(Path() + ".v0.bak")
+	if err != nil || string(backup) != legacy {
+		t.Errorf("backup: got %q, %v; want the legacy file", backup, err)
+	}
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseFile(data)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"schema_version": 1`) || f.CurrentProfile != DefaultProfile || f.Profiles[DefaultProfile].AccountID != "abc" {
+		t.Errorf("unexpected file after migration: %s", data)
+	}
+}
+
+func TestLoadNewerSchema(t *testing.T) {
+	testutil.SetHome(t)
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(Path(), []byte(`{"schema_version": 99, "profiles": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "upgrade freshtime") {
+		t.Errorf("expected an error asking to upgrade, got %v", err)
+	}
+}
+
+func TestXDGDirs(t *testing.T) {
+	home := testutil.SetHome(t)
+	t.Setenv("XDG_STATE_HOME", "relative/is/ignored")
+	if want := filepath.Join(home, ".config", "freshtime", "config.json"); Path() != want {
+		t.Errorf("Path: got %q, want %q", Path(), want)
+	}
+	if want := filepath.Join(home, ".local", "state", "freshtime"); StateHome() != want {
+		t.Errorf("StateHome: got %q, want %q", StateHome(), want)
+	}
+
+}
+
+func TestMoveLegacyConfig(t *testing.T) {
+	home := testutil.SetHome(t)
+	legacy := filepath.Join(home, ".config", "freshtime")
+	if err := Save(&Config{AccessToken: "legacy", BusinessID: 7}); err != nil {
+		t.Fatal(err)
+	}
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	dir := filepath.Join(xdg, "freshtime")
+	if want := filepath.Join(dir, "config.json"); Path() != want {
+		t.Errorf("Path: got %q, want %q", Path(), want)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.AccessToken != "legacy" || cfg.BusinessID != 7 {
+		t.Errorf("got %+v, want the legacy profile", cfg)
+	}
+	for _, name := range []string{"config.json", "credentials.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was not moved: %v", name, err)
+		}
+	}
+	for name, want := range map[string]bool{"config.json": false, "credentials.json": false, "config.json.bak": true} {
+		if _, err := os.Stat(filepath.Join(legacy, name)); (err == nil) != want {
+			t.Errorf("legacy %s: exists = %v, want %v", name, err == nil, want)
+		}
+	}
+
+	// Saving writes to XDG_CONFIG_HOME from now on.
+	cfg.BusinessID = 8
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "config.json")); err == nil {
+		t.Error("Save wrote the legacy config")
+	}
+	if cfg, err := Load(); err != nil || cfg.BusinessID != 8 || cfg.AccessToken != "legacy" {
+		t.Errorf("after Save: got %+v, %v", cfg, err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	testutil.SetHome(t)
+	t.Cleanup(func() { Profile = "" })
+
+	if err := Save(&Config{AccessToken: "t1", BusinessID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(&Config{Name: "acme", AccessToken: "t2", BusinessID: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first profile saved stays current.
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != DefaultProfile || cfg.BusinessID != 1 {
+		t.Errorf("current profile: got %s/%d, want default/1", cfg.Name, cfg.BusinessID)
+	}
+
+	Profile = "acme"
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "acme" || cfg.BusinessID != 2 {
+		t.Errorf("selected profile: got %s/%d, want acme/2", cfg.Name, cfg.BusinessID)
+	}
+
+	// Saving a loaded profile writes back to it without touching others.
+	cfg.AccessToken = "t2-refreshed"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"acme": "t2-refreshed", DefaultProfile: "t1"} {
+		Profile = name
+		cfg, err := Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.AccessToken != want {
+			t.Errorf("%s token: got %q, want %q", name, cfg.AccessToken, want)
+		}
+	}
+
+	Profile = "missing"
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), `profile "missing" not found`) {
+		t.Errorf("expected missing profile error, got %v", err)
+	}
+}
@@ -0,0 +1,362 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Credential store backends.
+const (
+	// StoreInline keeps tokens in config.json itself, as freshtime always did.
+	StoreInline = "inline"
+	// StoreFile keeps tokens in credentials.json, readable only by the owner.
+	StoreFile = "file"
+	// StoreEncrypted keeps tokens in credentials.enc, encrypted with AES-GCM.
+	StoreEncrypted = "encrypted"
+)
+
+// PassphraseEnv supplies the passphrase for the encrypted credential store.
+const PassphraseEnv = "FRESHTIME_PASSPHRASE"
+
+// KeyFileEnv points at a key file for the encrypted credential store,
+// overriding the key file recorded in the config.
+const KeyFileEnv = "FRESHTIME_KEY_FILE"
+
+// pbkdf2Iterations is the PBKDF2-SHA256 work factor for passphrase-derived keys.
+const pbkdf2Iterations = 600_000
+
+// Credentials are the OAuth tokens of a single profile.
+type Credentials struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitzero"`
+	CreatedAt    time.Time `json:"created_at,omitzero"`
+	Scope        string    `json:"scope,omitempty"`
+}
+
+// CredentialStore persists credentials per profile, separately from the rest
+// of the config.
+type CredentialStore interface {
+	// Load returns the credentials for profile, or nil if there are none.
+	Load(profile string) (*Credentials, error)
+	// Save stores the credentials for profile.
+	Save(profile string, creds *Credentials) error
+	// Delete removes the credentials for profile.
+	Delete(profile string) error
+}
+
+// NewCredentialStore returns the backend named kind. keyFile is used by the
+// encrypted backend; when empty it falls back to FRESHTIME_KEY_FILE and then
+// to a passphrase from FRESHTIME_PASSPHRASE. StoreInline has no separate
+// store and yields nil.
+func NewCredentialStore(kind, keyFile string) (CredentialStore, error) {
+	switch kind {
+	case "", StoreInline:
+		return nil, nil
+	case StoreFile:
+		return &fileStore{path: filepath.Join(configDir(), "credentials.json")}, nil
+	case StoreEncrypted:
+		if env := os.Getenv(KeyFileEnv); env != "" {
+			keyFile = env
+		}
+		return &encryptedStore{path: filepath.Join(configDir(), "credentials.enc"), keyFile: keyFile}, nil
+	}
+	return nil, fmt.Errorf("unknown credential store %q (expected %s, %s or %s)", kind, StoreInline, StoreFile, StoreEncrypted)
+}
+
+// credentialMap is the decoded contents of a credential store file.
+type credentialMap map[string]*Credentials
+
+// updateCredentials sets or, when creds is nil, removes the entry for profile.
+func updateCredentials(m credentialMap, profile string, creds *Credentials) credentialMap {
+	if m == nil {
+		m = make(credentialMap)
+	}
+	if creds == nil {
+		delete(m, profile)
+	} else {
+		m[profile] = creds
+	}
+	return m
+}
+
+// fileStore keeps credentials in a plaintext JSON file with mode 0600.
+type fileStore struct {
+	path string
+}
+
+func (s *fileStore) read() (credentialMap, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m credentialMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", s.path, err)
+	}
+	return m, nil
+}
+
+func (s *fileStore) write(m credentialMap) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return writeFileAtomic(s.path, data, 0o600)
+}
+
+func (s *fileStore) Load(profile string) (*Credentials, error) {
+	m, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return m[profile], nil
+}
+
+func (s *fileStore) Save(profile string, creds *Credentials) error {
+	m, err := s.read()
+	if err != nil {
+		return err
+	}
+	return s.write(updateCredentials(m, profile, creds))
+}
+
+func (s *fileStore) Delete(profile string) error {
+	return s.Save(profile, nil)
+}
+
+// encryptedStore keeps credentials in a file encrypted with AES-256-GCM. The
+// key is derived from a passphrase with PBKDF2, or from a key file.
+type encryptedStore struct {
+	path    string
+	keyFile string
+}
+
+// encryptedFile is the on-disk envelope of an encrypted credential store.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"` // "pbkdf2-sha256" or "keyfile"
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// key derives the AES key for env. A new envelope (empty KDF) uses the key
+// file if one is configured and a passphrase otherwise, with a fresh salt.
+func (s *encryptedStore) key(env *encryptedFile) ([]byte, error) {
+	if env.KDF == "" {
+		env.KDF = "pbkdf2-sha256"
+		if s.keyFile != "" {
+			env.KDF = "keyfile"
+		}
+	}
+
+	switch env.KDF {
+	case "keyfile":
+		if s.keyFile == "" {
+			return nil, fmt.Errorf("encrypted credentials in %s need a key file; set %s or credential_key_file", s.path, KeyFileEnv)
+		}
+		secret, err := os.ReadFile(s.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("key file %s is too short (need at least 32 bytes)", s.keyFile)
+		}
+		sum := sha256.Sum256(secret)
+		return sum[:], nil
+	case "pbkdf2-sha256":
+		passphrase := os.Getenv(PassphraseEnv)
+		if passphrase == "" {
+			return nil, fmt.Errorf("encrypted credentials in %s need a passphrase; set %s", s.path, PassphraseEnv)
+		}
+		if env.Salt == nil {
+			env.Salt = make([]byte, 16)
+			if _, err := rand.Read(env.Salt); err != nil {
+				return nil, err
+			}
+			env.Iterations = pbkdf2Iterations
+		}
+		return pbkdf2.Key(sha256.New, passphrase, env.Salt, env.Iterations, 32)
+	}
+	return nil, fmt.Errorf("unsupported key derivation %q in %s", env.KDF, s.path)
+}
+
+func (s *encryptedStore) read() (credentialMap, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var env encryptedFile
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", s.path, err)
+	}
+	key, err := s.key(&env)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: wrong passphrase or key file", s.path)
+	}
+	var m credentialMap
+	if err := json.Unmarshal(plain, &m); err != nil {
+		return nil, fmt.Errorf("invalid credentials in %s: %w", s.path, err)
+	}
+	return m, nil
+}
+
+func (s *encryptedStore) write(m credentialMap) error {
+	plain, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	env := encryptedFile{Version: 1}
+	key, err := s.key(&env)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return err
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data, 0o600)
+}
+
+func (s *encryptedStore) Load(profile string) (*Credentials, error) {
+	m, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return m[profile], nil
+}
+
+func (s *encryptedStore) Save(profile string, creds *Credentials) error {
+	m, err := s.read()
+	if err != nil {
+		return err
+	}
+	return s.write(updateCredentials(m, profile, creds))
+}
+
+func (s *encryptedStore) Delete(profile string) error {
+	return s.Save(profile, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// MigrateCredentials moves the tokens of every profile into the credential
+// store kind and records it in the config file. The new store is written
+// before the old copies are deleted, so an interrupted migration leaves the
+// tokens readable from the old store. It returns the number of profiles moved.
+func MigrateCredentials(kind, keyFile string) (int, error) {
+	f, err := LoadFile()
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("config not found. Run `freshtime setup` to configure your token")
+	}
+	if err != nil {
+		return 0, err
+	}
+	current := f.CredentialStore
+	if current == "" {
+		current = StoreInline
+	}
+	if kind == current {
+		return 0, fmt.Errorf("credentials are already in the %s store", kind)
+	}
+
+	from, err := f.Store()
+	if err != nil {
+		return 0, err
+	}
+	to, err := NewCredentialStore(kind, keyFile)
+	if err != nil {
+		return 0, err
+	}
+
+	moved := make(map[string]*Credentials)
+	for _, name := range f.Names() {
+		creds := f.Profiles[name].Credentials()
+		if from != nil {
+			if creds, err = from.Load(name); err != nil {
+				return 0, fmt.Errorf("failed to read credentials: %w", err)
+			}
+		}
+		if creds == nil || creds.AccessToken == "" {
+			continue
+		}
+		moved[name] = creds
+	}
+
+	for name, creds := range moved {
+		if to == nil {
+			f.Profiles[name].setCredentials(creds)
+			continue
+		}
+		if err := to.Save(name, creds); err != nil {
+			return 0, fmt.Errorf("failed to write credentials: %w", err)
+		}
+	}
+	f.CredentialStore = kind
+	f.CredentialKeyFile = ""
+	if kind == StoreEncrypted {
+		f.CredentialKeyFile = keyFile
+	}
+	if err := f.Save(); err != nil {
+		return 0, err
+	}
+
+	if from != nil {
+		for name := range moved {
+			if err := from.Delete(name); err != nil {
+				return len(moved), fmt.Errorf("credentials moved, but removing the old copy failed: %w", err)
+			}
+		}
+	}
+	return len(moved), nil
+}
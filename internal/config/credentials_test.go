@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hev/freshtime/internal/testutil"
+)
+
+func TestFileStorePermissions(t *testing.T) {
+	testutil.SetHome(t)
+
+	if err := Save(&Config{AccessToken: "secret-token", BusinessID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"config.json", "credentials.json"} {
+		info, err := os.Stat(filepath.Join(configDir(), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s: mode %o, want 600", name, perm)
+		}
+	}
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Errorf("config.json contains the access token:\n%s", data)
+	}
+}
+
+func TestEncryptedStore(t *testing.T) {
+	testutil.SetHome(t)
+	t.Setenv(PassphraseEnv, "correct horse")
+
+	store, err := NewCredentialStore(StoreEncrypted, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("acme", &Credentials{AccessToken: "secret-token", RefreshToken: "r"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir(), "credentials.enc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("credentials.enc contains the plaintext token")
+	}
+
+	creds, err := store.Load("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds == nil || creds.AccessToken != "secret-token" || creds.RefreshToken != "r" {
+		t.Errorf("got %+v", creds)
+	}
+
+	t.Setenv(PassphraseEnv, "wrong")
+	if _, err := store.Load("acme"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("expected decryption error, got %v", err)
+	}
+}
+
+func TestMigrateCredentials(t *testing.T) {
+	testutil.SetHome(t)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("k", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Start from a legacy config with tokens inline.
+	if err := os.MkdirAll(configDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"access_token": "inline-token", "refresh_token": "inline-refresh", "business_id": 7}`
+	if err := os.WriteFile(Path(), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kind := range []string{StoreEncrypted, StoreFile, StoreInline} {
+		n, err := MigrateCredentials(kind, keyFile)
+		if err != nil {
+			t.Fatalf("migrate to %s: %v", kind, err)
+		}
+		if n != 1 {
+			t.Errorf("migrate to %s: moved %d profiles, want 1", kind, n)
+		}
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("load after migrating to %s: %v", kind, err)
+		}
+		if cfg.AccessToken != "inline-token" || cfg.RefreshToken != "inline-refresh" {
+			t.Errorf("after migrating to %s: got tokens %q/%q", kind, cfg.AccessToken, cfg.RefreshToken)
+		}
+
+		data, err := os.ReadFile(Path())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inline := strings.Contains(string(data), "inline-token"); inline != (kind == StoreInline) {
+			t.Errorf("after migrating to %s: token in config.json = %v", kind, inline)
+		}
+	}
+
+	if _, err := MigrateCredentials(StoreInline, ""); err == nil {
+		t.Error("expected error migrating to the current store")
+	}
+}
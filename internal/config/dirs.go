@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// xdgDir returns the freshtime directory under the XDG base directory named
+// by env, or under ~/fallback when env is unset or not an absolute path, as
+// the XDG Base Directory Specification requires.
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "freshtime")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, fallback, "freshtime")
+}
+
+// ConfigHome returns the directory for the config file and credential
+// stores: $XDG_CONFIG_HOME/freshtime, by default ~/.config/freshtime.
+func ConfigHome() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// StateHome returns the directory for state that should survive restarts
+// but is not configuration, such as a running timer:
+// $XDG_STATE_HOME/freshtime, by default ~/.local/state/freshtime.
+func StateHome() string {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// LegacyDir returns ~/.config/freshtime, where versions before XDG support
+// kept all of their files regardless of XDG_CONFIG_HOME.
+func LegacyDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "freshtime")
+}
@@ -0,0 +1,532 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clientRatesPrefix starts the dynamic keys that set a client's hourly rate.
+const clientRatesPrefix = "client_rates."
+
+// clientRoundingPrefix starts the dynamic keys that set a client's rounding
+// rule, client_rounding.<client-id>.<increment|mode|minimum>.
+const clientRoundingPrefix = "client_rounding."
+
+var roundingFields = []string{"increment", "mode", "minimum"}
+
+// Setting is a key understood by `freshtime config`.
+type Setting struct {
+	Key  string
+	Help string
+	// Secret values are masked unless explicitly requested.
+	Secret bool
+	// Global settings live at the top of the config file and are shared
+	// by all profiles; the rest belong to the active profile.
+	Global bool
+	// ReadOnly, if set, explains how the value is changed instead.
+	ReadOnly string
+
+	get func(f *File, c *Config) string
+	set func(f *File, c *Config, v string) error // v == "" unsets
+}
+
+// Entry is a config key with its current value.
+type Entry struct {
+	Key    string
+	Value  string
+	Secret bool
+}
+
+var settings = []Setting{
+	{
+		Key: "account_id", Help: "FreshBooks account ID used for accounting endpoints",
+		get: func(_ *File, c *Config) string { return c.AccountID },
+		set: func(_ *File, c *Config, v string) error { c.AccountID = v; return nil },
+	},
+	{
+		Key: "business_id", Help: "FreshBooks business ID used for time tracking",
+		get: func(_ *File, c *Config) string { return formatInt(c.BusinessID) },
+		set: func(_ *File, c *Config, v string) error {
+			id, err := parseID(v)
+			c.BusinessID = id
+			return err
+		},
+	},
+	{
+		Key: "default_currency", Help: "ISO 4217 currency for invoices, e.g. USD",
+		get: func(_ *File, c *Config) string { return c.DefaultCurrency },
+		set: func(_ *File, c *Config, v string) error {
+			code, err := normalizeCurrency(v)
+			c.DefaultCurrency = code
+			return err
+		},
+	},
+	roundingSetting("rounding.increment", "Round logged and invoiced time to this step, e.g. 15m",
+		profileRounding, setProfileRounding),
+	roundingSetting("rounding.mode", "Rounding direction: nearest, up or down",
+		profileRounding, setProfileRounding),
+	roundingSetting("rounding.minimum", "Never log or invoice less than this, e.g. 15m",
+		profileRounding, setProfileRounding),
+	{
+		Key: "access_token", Help: "OAuth access token", Secret: true,
+		get: func(_ *File, c *Config) string { return c.AccessToken },
+		set: func(_ *File, c *Config, v string) error { c.AccessToken = v; return nil },
+	},
+	{
+		Key: "refresh_token", Help: "OAuth refresh token", Secret: true,
+		get: func(_ *File, c *Config) string { return c.RefreshToken },
+		set: func(_ *File, c *Config, v string) error { c.RefreshToken = v; return nil },
+	},
+	{
+		Key: "token_expires_at", Help: "When the access token expires",
+		ReadOnly: "it is updated whenever the token is refreshed",
+		get:      func(_ *File, c *Config) string { return formatTime(c.TokenExpiresAt) },
+	},
+	{
+		Key: "token_scope", Help: "Scopes granted to the access token",
+		ReadOnly: "it is set by `freshtime setup`",
+		get:      func(_ *File, c *Config) string { return c.TokenScope },
+	},
+	{
+		Key: "credential_store", Help: "Where tokens are kept: inline, file or encrypted", Global: true,
+		ReadOnly: "use `freshtime auth migrate --to <store>`",
+		get:      func(f *File, _ *Config) string { return f.CredentialStore },
+	},
+	{
+		Key: "credential_key_file", Help: "Key file for the encrypted credential store", Global: true,
+		ReadOnly: "use `freshtime auth migrate --to encrypted --key-file <path>`",
+		get:      func(f *File, _ *Config) string { return f.CredentialKeyFile },
+	},
+	{
+		Key: "oauth.redirect_uri", Help: "Redirect URI registered for the FreshBooks app", Global: true,
+		get: func(f *File, _ *Config) string { return f.OAuth.RedirectURI },
+		set: func(f *File, _ *Config, v string) error {
+			f.OAuth.RedirectURI = v
+			return validateRedirectURI(v)
+		},
+	},
+	{
+		Key: "oauth.callback_addr", Help: "Loopback host:port for the setup callback server", Global: true,
+		get: func(f *File, _ *Config) string { return f.OAuth.CallbackAddr },
+		set: func(f *File, _ *Config, v string) error {
+			f.OAuth.CallbackAddr = v
+			return validateCallbackAddr(v)
+		},
+	},
+	{
+		Key: "oauth.callback_tls", Help: "Serve the setup callback over TLS: auto, on or off", Global: true,
+		get: func(f *File, _ *Config) string { return f.OAuth.CallbackTLS },
+		set: func(f *File, _ *Config, v string) error {
+			f.OAuth.CallbackTLS = v
+			return validateTLSMode(v)
+		},
+	},
+	{
+		Key: "oauth.callback_timeout", Help: "How long setup waits for authorization, e.g. 5m", Global: true,
+		get: func(f *File, _ *Config) string { return f.OAuth.CallbackTimeout },
+		set: func(f *File, _ *Config, v string) error {
+			f.OAuth.CallbackTimeout = v
+			return validateTimeout(v)
+		},
+	},
+}
+
+// Settings returns the fixed config keys in display order. Client rates
+// and rounding rules are set with the additional keys
+// client_rates.<client-id> and client_rounding.<client-id>.<field>.
+func Settings() []Setting {
+	return settings
+}
+
+// LookupSetting returns the setting for key, including client_rates.<client-id>
+// and client_rounding.<client-id>.<field>.
+func LookupSetting(key string) (*Setting, error) {
+	if id, ok := strings.CutPrefix(key, clientRatesPrefix); ok {
+		if _, err := parseID(id); err != nil || id == "" {
+			return nil, fmt.Errorf("invalid key %q: expected client_rates.<client-id>", key)
+		}
+		return clientRateSetting(id), nil
+	}
+	if rest, ok := strings.CutPrefix(key, clientRoundingPrefix); ok {
+		id, field, _ := strings.Cut(rest, ".")
+		if _, err := parseID(id); err != nil || id == "" || !slices.Contains(roundingFields, field) {
+			return nil, fmt.Errorf("invalid key %q: expected client_rounding.<client-id>.<increment|mode|minimum>", key)
+		}
+		return clientRoundingSetting(id, field), nil
+	}
+	for i := range settings {
+		if settings[i].Key == key {
+			return &settings[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown config key %q (see `freshtime config list --all`)", key)
+}
+
+func clientRateSetting(id string) *Setting {
+	return &Setting{
+		Key:  clientRatesPrefix + id,
+		Help: "Hourly rate for client " + id,
+		get:  func(_ *File, c *Config) string { return c.ClientRates[id] },
+		set: func(_ *File, c *Config, v string) error {
+			if v == "" {
+				delete(c.ClientRates, id)
+				return nil
+			}
+			rate, err := normalizeRate(v)
+			if err != nil {
+				return err
+			}
+			if c.ClientRates == nil {
+				c.ClientRates = make(map[string]string)
+			}
+			c.ClientRates[id] = rate
+			return nil
+		},
+	}
+}
+
+// roundingSetting returns the setting for one field of a rounding rule,
+// read with load and written with store; the field is the last part of key.
+// The rule is removed once none of its fields are set.
+func roundingSetting(key, help string, load func(c *Config) *Rounding, store func(c *Config, r *Rounding)) Setting {
+	field := key[strings.LastIndex(key, ".")+1:]
+	return Setting{
+		Key: key, Help: help,
+		get: func(_ *File, c *Config) string {
+			if r := load(c); r != nil {
+				return *r.field(field)
+			}
+			return ""
+		},
+		set: func(_ *File, c *Config, v string) error {
+			r := &Rounding{}
+			if old := load(c); old != nil {
+				*r = *old
+			}
+			*r.field(field) = strings.TrimSpace(v)
+			if *r == (Rounding{}) {
+				store(c, nil)
+				return nil
+			}
+			store(c, r)
+			_, err := r.Rule()
+			return err
+		},
+	}
+}
+
+func profileRounding(c *Config) *Rounding { return c.Rounding }
+
+func setProfileRounding(c *Config, r *Rounding) { c.Rounding = r }
+
+func clientRoundingSetting(id, field string) *Setting {
+	s := roundingSetting(clientRoundingPrefix+id+"."+field, "Rounding "+field+" for client "+id,
+		func(c *Config) *Rounding { return c.ClientRounding[id] },
+		func(c *Config, r *Rounding) {
+			if r == nil {
+				delete(c.ClientRounding, id)
+				return
+			}
+			if c.ClientRounding == nil {
+				c.ClientRounding = make(map[string]*Rounding)
+			}
+			c.ClientRounding[id] = r
+		})
+	return &s
+}
+
+// Get returns the value of key for the active profile, or "" when unset.
+func Get(key string) (string, error) {
+	s, err := LookupSetting(key)
+	if err != nil {
+		return "", err
+	}
+	f, cfg, err := loadForSetting(s)
+	if err != nil {
+		return "", err
+	}
+	return s.get(f, cfg), nil
+}
+
+// Set validates value and stores it under key, in the active profile unless
+// the key is global.
+func Set(key, value string) error {
+	if value == "" {
+		return fmt.Errorf("empty value for %s; use unset to remove it", key)
+	}
+	return update(key, value)
+}
+
+// Unset removes key from the active profile or, for global keys, the file.
+func Unset(key string) error {
+	return update(key, "")
+}
+
+func update(key, value string) error {
+	s, err := LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	if s.ReadOnly != "" {
+		return fmt.Errorf("%s cannot be changed with config set: %s", key, s.ReadOnly)
+	}
+	f, cfg, err := loadForSetting(s)
+	if err != nil {
+		return err
+	}
+	if err := s.set(f, cfg, value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	if s.Global {
+		return f.Save()
+	}
+	return Save(cfg)
+}
+
+// List returns every key that has a value in the active profile or the file,
+// followed by client rates and rounding rules in client ID order. With all set, unset fixed keys
+// are included with empty values.
+func List(all bool) ([]Entry, error) {
+	f, err := LoadFile()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("config not found. Run `freshtime setup` to configure your token")
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, s := range settings {
+		if v := s.get(f, cfg); v != "" || all {
+			entries = append(entries, Entry{Key: s.Key, Value: v, Secret: s.Secret})
+		}
+	}
+	return append(entries, clientEntries(cfg)...), nil
+}
+
+// clientEntries returns the client rates and then the client rounding
+// rules set in cfg, each in client ID order.
+func clientEntries(cfg *Config) []Entry {
+	var entries []Entry
+	for _, id := range sortedIDs(cfg.ClientRates) {
+		entries = append(entries, Entry{Key: clientRatesPrefix + id, Value: cfg.ClientRates[id]})
+	}
+	for _, id := range sortedIDs(cfg.ClientRounding) {
+		r := cfg.ClientRounding[id]
+		if r == nil {
+			continue
+		}
+		for _, field := range roundingFields {
+			if v := *r.field(field); v != "" {
+				entries = append(entries, Entry{Key: clientRoundingPrefix + id + "." + field, Value: v})
+			}
+		}
+	}
+	return entries
+}
+
+// sortedIDs returns the client ID keys of m in numeric order.
+func sortedIDs[V any](m map[string]V) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+	return ids
+}
+
+// loadForSetting loads what s needs: the file for global keys, the active
+// profile (with its credentials) otherwise.
+func loadForSetting(s *Setting) (*File, *Config, error) {
+	if s.Global {
+		f, err := LoadFile()
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, err
+		}
+		return f, nil, nil
+	}
+	cfg, err := Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, cfg, nil
+}
+
+// Validate checks every value in f the same way `freshtime config set` would.
+func (f *File) Validate() error {
+	if _, err := NewCredentialStore(f.CredentialStore, ""); err != nil {
+		return err
+	}
+	if f.CurrentProfile != "" {
+		if _, ok := f.Profiles[f.CurrentProfile]; !ok {
+			return fmt.Errorf("current_profile %q does not exist", f.CurrentProfile)
+		}
+	}
+	for _, check := range []struct {
+		key string
+		err error
+	}{
+		{"oauth.redirect_uri", validateRedirectURI(f.OAuth.RedirectURI)},
+		{"oauth.callback_addr", validateCallbackAddr(f.OAuth.CallbackAddr)},
+		{"oauth.callback_tls", validateTLSMode(f.OAuth.CallbackTLS)},
+		{"oauth.callback_timeout", validateTimeout(f.OAuth.CallbackTimeout)},
+	} {
+		if check.err != nil {
+			return fmt.Errorf("%s: %w", check.key, check.err)
+		}
+	}
+
+	for _, name := range f.Names() {
+		cfg := f.Profiles[name]
+		if cfg.BusinessID < 0 {
+			return fmt.Errorf("profile %s: business_id: must be a positive integer", name)
+		}
+		if cfg.DefaultCurrency != "" && !ValidCurrency(cfg.DefaultCurrency) {
+			return fmt.Errorf("profile %s: default_currency: %q is not an ISO 4217 currency code", name, cfg.DefaultCurrency)
+		}
+		for id, rate := range cfg.ClientRates {
+			if _, err := parseID(id); err != nil {
+				return fmt.Errorf("profile %s: client_rates: invalid client ID %q", name, id)
+			}
+			if _, err := normalizeRate(rate); err != nil {
+				return fmt.Errorf("profile %s: client_rates.%s: %w", name, id, err)
+			}
+		}
+		if _, err := cfg.Rounding.Rule(); err != nil {
+			return fmt.Errorf("profile %s: rounding: %w", name, err)
+		}
+		for id, r := range cfg.ClientRounding {
+			if _, err := parseID(id); err != nil {
+				return fmt.Errorf("profile %s: client_rounding: invalid client ID %q", name, id)
+			}
+			if _, err := r.Rule(); err != nil {
+				return fmt.Errorf("profile %s: client_rounding.%s: %w", name, id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MaskSecret hides all but the last four characters of a secret value.
+func MaskSecret(v string) string {
+	if len(v) <= 16 {
+		return strings.Repeat("*", len(v))
+	}
+	return strings.Repeat("*", 8) + v[len(v)-4:]
+}
+
+func parseID(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%q is not a positive integer ID", v)
+	}
+	return id, nil
+}
+
+func normalizeRate(v string) (string, error) {
+	rate, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return "", fmt.Errorf("rate %q is not a number", v)
+	}
+	if rate < 0 {
+		return "", fmt.Errorf("rate %q is negative", v)
+	}
+	return strings.TrimSpace(v), nil
+}
+
+func normalizeCurrency(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	code := strings.ToUpper(strings.TrimSpace(v))
+	if !ValidCurrency(code) {
+		return "", fmt.Errorf("%q is not an ISO 4217 currency code", v)
+	}
+	return code, nil
+}
+
+func validateRedirectURI(v string) error {
+	if v == "" {
+		return nil
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("%q is not an http(s) URL", v)
+	}
+	return nil
+}
+
+func validateCallbackAddr(v string) error {
+	if v == "" {
+		return nil
+	}
+	return CheckLoopback(v)
+}
+
+// CheckLoopback rejects listen addresses that are reachable from other
+// machines, so the setup callback server only ever binds to loopback.
+func CheckLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("%q is not a host:port address", addr)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("callback address %q is not a loopback address; use 127.0.0.1, ::1 or localhost", addr)
+}
+
+func validateTLSMode(v string) error {
+	switch v {
+	case "", "auto", "on", "off":
+		return nil
+	}
+	return fmt.Errorf("%q is not one of auto, on or off", v)
+}
+
+func validateTimeout(v string) error {
+	if v == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+		return fmt.Errorf("%q is not a positive duration", v)
+	}
+	return nil
+}
+
+func formatInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hev/freshtime/internal/testutil"
+)
+
+func TestSetGetUnset(t *testing.T) {
+	testutil.SetHome(t)
+	if err := Save(&Config{AccessToken: "t", AccountID: "abc", BusinessID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, value := range map[string]string{
+		"client_rates.123":       "150.00",
+		"default_currency":       "eur",
+		"oauth.callback_timeout": "2m",
+	} {
+		if err := Set(key, value); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+
+	for key, want := range map[string]string{
+		"client_rates.123":       "150.00",
+		"default_currency":       "EUR",
+		"oauth.callback_timeout": "2m",
+		"account_id":             "abc",
+		"business_id":            "1",
+		"client_rates.999":       "",
+	} {
+		got, err := Get(key)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", key, err)
+		}
+		if got != want {
+			t.Errorf("Get(%s) = %q, want %q", key, got, want)
+		}
+	}
+
+	if err := Unset("client_rates.123"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.ClientRates) != 0 || cfg.AccessToken != "t" {
+		t.Errorf("after unset: %+v", cfg)
+	}
+}
+
+func TestSetValidation(t *testing.T) {
+	testutil.SetHome(t)
+	if err := Save(&Config{AccessToken: "t", BusinessID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key, value, wantErr string
+	}{
+		{"client_rates.123", "abc", "not a number"},
+		{"client_rates.123", "-5", "negative"},
+		{"client_rates.abc", "100", "invalid key"},
+		{"default_currency", "XYZ", "ISO 4217"},
+		{"business_id", "zero", "positive integer"},
+		{"oauth.callback_tls", "maybe", "auto, on or off"},
+		{"oauth.redirect_uri", "localhost:8457", "http(s) URL"},
+		{"oauth.callback_addr", "0.0.0.0:8457", "not a loopback address"},
+		{"oauth.callback_addr", "8457", "host:port"},
+		{"token_scope", "all", "cannot be changed"},
+		{"credential_store", "file", "auth migrate"},
+		{"nope", "1", "unknown config key"},
+		{"rounding.increment", "7s", "whole minutes"},
+		{"rounding.mode", "sideways", "nearest, up or down"},
+		{"client_rounding.123.minimum", "25h", "whole minutes"},
+		{"client_rounding.123.step", "6m", "invalid key"},
+		{"client_rounding.abc.mode", "up", "invalid key"},
+	}
+	for _, tt := range tests {
+		err := Set(tt.key, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Set(%s, %s) = %v, want error containing %q", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRoundingKeys(t *testing.T) {
+	testutil.SetHome(t)
+	if err := Save(&Config{AccessToken: "t", BusinessID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range [][2]string{
+		{"rounding.increment", "15m"},
+		{"client_rounding.7.increment", "6m"},
+		{"client_rounding.7.mode", "up"},
+		{"client_rounding.7.minimum", "15m"},
+	} {
+		if err := Set(kv[0], kv[1]); err != nil {
+			t.Fatalf("Set(%s): %v", kv[0], err)
+		}
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A project file's rule wins over the config's, but only for its own
+	// client.
+	project := &ProjectConfig{ClientID: 7, Rounding: &Rounding{Increment: "30m"}}
+	clientless := &ProjectConfig{Rounding: &Rounding{Increment: "30m"}}
+	for _, tt := range []struct {
+		cfg        *Config
+		client     int
+		pc         *ProjectConfig
+		want, from string
+	}{
+		{cfg, 7, nil, "6m up, minimum 15m", "client_rounding.7"},
+		{cfg, 8, nil, "15m nearest", "rounding"},
+		{cfg, 7, project, "30m nearest", ""},
+		{cfg, 8, project, "15m nearest", "rounding"},
+		{cfg, 7, clientless, "6m up, minimum 15m", "client_rounding.7"},
+		{&Config{}, 8, project, "", ""},
+		{&Config{}, 7, clientless, "", ""},
+	} {
+		r, from := tt.cfg.RoundingFor(tt.client, tt.pc)
+		if got := r.String(); got != tt.want || from != tt.from {
+			t.Errorf("RoundingFor(%d, %+v) = %q from %q, want %q from %q", tt.client, tt.pc, got, from, tt.want, tt.from)
+		}
+	}
+
+	entries, err := List(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range entries {
+		if strings.Contains(e.Key, "rounding") {
+			keys = append(keys, e.Key+"="+e.Value)
+		}
+	}
+	want := "rounding.increment=15m client_rounding.7.increment=6m client_rounding.7.mode=up client_rounding.7.minimum=15m"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("listed %q, want %q", got, want)
+	}
+
+	for _, key := range []string{"client_rounding.7.increment", "client_rounding.7.mode", "client_rounding.7.minimum"} {
+		if err := Unset(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.ClientRounding) != 0 || cfg.Rounding == nil {
+		t.Errorf("after unsetting client 7: %+v", cfg)
+	}
+}
+
+func TestList(t *testing.T) {
+	testutil.SetHome(t)
+	cfg := &Config{
+		AccessToken: "secret",
+		BusinessID:  1,
+		ClientRates: map[string]string{"20": "100", "3": "90"},
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+		if e.Key == "access_token" && !e.Secret {
+			t.Error("access_token not marked secret")
+		}
+	}
+	want := "business_id access_token credential_store client_rates.3 client_rates.20"
+	if got := strings.Join(keys, " "); got != want {
+		t.Errorf("keys = %q, want %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f, err := ParseFile([]byte(`{"profiles": {"a": {"default_currency": "usd"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Validate(); err == nil || !strings.Contains(err.Error(), "default_currency") {
+		t.Errorf("expected currency error, got %v", err)
+	}
+
+	f, err = ParseFile([]byte(`{"profiles": {}, "oauth": {"callback_addr": "192.168.1.5:8457"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Validate(); err == nil || !strings.Contains(err.Error(), "oauth.callback_addr") {
+		t.Errorf("expected callback_addr error, got %v", err)
+	}
+
+	f, err = ParseFile([]byte(`{"current_profile": "a", "profiles": {"a": {"client_rates": {"1": "100"}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	if got := MaskSecret("short"); got != "*****" {
+		t.Errorf("MaskSecret(short) = %q", got)
+	}
+	if got := MaskSecret("abcdefghijklmnopqrstuvwxyz"); got != "********wxyz" {
+		t.Errorf("MaskSecret(long) = %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentSchemaVersion is the config file layout written by this version of
+// freshtime. Files without a schema_version are version 0.
+const CurrentSchemaVersion = 1
+
+// migrations[v] upgrades a decoded config file from schema version v to
+// v+1. Append to this list, never edit an entry, when the layout changes.
+var migrations = []func(map[string]json.RawMessage) error{
+	0: migrateToProfiles,
+}
+
+// migrateToProfiles moves a single-business config, where the whole file is
+// one profile, into the named profiles layout as DefaultProfile. Files
+// that already have profiles only gain a version.
+func migrateToProfiles(doc map[string]json.RawMessage) error {
+	if _, ok := doc["profiles"]; ok {
+		return nil
+	}
+	profile, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	profiles, err := json.Marshal(map[string]json.RawMessage{DefaultProfile: profile})
+	if err != nil {
+		return err
+	}
+	current, _ := json.Marshal(DefaultProfile)
+	clear(doc)
+	doc["current_profile"] = current
+	doc["profiles"] = profiles
+	return nil
+}
+
+// migrate upgrades the contents of a config file to CurrentSchemaVersion. It
+// returns the upgraded contents and the version they were upgraded from.
+func migrate(data []byte) ([]byte, int, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("invalid config file: %w", err)
+	}
+	if doc == nil {
+		return nil, 0, fmt.Errorf("invalid config file: not a JSON object")
+	}
+	from := 0
+	if v, ok := doc["schema_version"]; ok {
+		if err := json.Unmarshal(v, &from); err != nil || from < 0 {
+			return nil, 0, fmt.Errorf("invalid config file: schema_version must be a non-negative integer")
+		}
+	}
+	if from > CurrentSchemaVersion {
+		return nil, 0, fmt.Errorf("config file schema version %d is newer than this freshtime supports (%d); upgrade freshtime", from, CurrentSchemaVersion)
+	}
+	if from == CurrentSchemaVersion {
+		return data, from, nil
+	}
+
+	for v := from; v < CurrentSchemaVersion; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, 0, fmt.Errorf("failed to migrate config from schema version %d: %w", v, err)
+		}
+	}
+	doc["schema_version"], _ = json.Marshal(CurrentSchemaVersion)
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, from, nil
+}
@@ -2,40 +2,279 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
 )
 
 const ProjectConfigFile = ".freshtime.json"
 
+// ErrNoProjectConfig is returned when no .freshtime.json applies to a
+// directory.
+var ErrNoProjectConfig = errors.New("no " + ProjectConfigFile + " found")
+
 // ProjectConfig holds per-project defaults for time logging.
 type ProjectConfig struct {
 	ClientID  int `json:"client_id,omitempty"`
 	ProjectID int `json:"project_id,omitempty"`
 	ServiceID int `json:"service_id,omitempty"`
+
+	// Profile selects the config profile used inside the project, unless
+	// --profile or FRESHTIME_PROFILE says otherwise.
+	Profile string `json:"profile,omitempty"`
+
+	// Billable is the default billable flag for new entries; unset means
+	// billable.
+	Billable *bool `json:"billable,omitempty"`
+
+	// Rate and Currency override the profile's client rate and default
+	// currency when invoicing ClientID from the project directory.
+	Rate     string `json:"rate,omitempty"`
+	Currency string `json:"currency,omitempty"`
+
+	// NoteTemplate is a text/template for entry notes, e.g.
+	// "[{{.Branch}}] {{.Message}}". Tags are available to it as .Tags and
+	// are appended to the note when there is no template.
+	NoteTemplate string   `json:"note_template,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+
+	// Rounding overrides the profile's rounding rules for ClientID, both
+	// for new entries and when invoicing from the project directory.
+	Rounding *Rounding `json:"rounding,omitempty"`
+
+	// Root stops the search for .freshtime.json files in parent
+	// directories, so nothing is inherited from them.
+	Root bool `json:"root,omitempty"`
+
+	paths   []string          // files merged into this config, outermost first
+	sources map[string]string // where each key was set
+}
+
+// projectEnv maps project keys to the environment variables that override
+// them.
+var projectEnv = []struct {
+	key string
+	env string
+}{
+	{"client_id", "FRESHTIME_CLIENT_ID"},
+	{"project_id", "FRESHTIME_PROJECT_ID"},
+	{"service_id", "FRESHTIME_SERVICE_ID"},
+}
+
+// Source describes where the value of key came from, or "" if it is unset.
+func (pc *ProjectConfig) Source(key string) string {
+	return pc.sources[key]
+}
+
+// Files returns the .freshtime.json files merged into pc, outermost first.
+func (pc *ProjectConfig) Files() []string {
+	return slices.Clone(pc.paths)
+}
+
+// Values returns the keys set in pc and their values, formatted for
+// display.
+func (pc *ProjectConfig) Values() []Entry {
+	var out []Entry
+	add := func(key, value string) {
+		if value != "" {
+			out = append(out, Entry{Key: key, Value: value})
+		}
+	}
+	add("client_id", formatInt(pc.ClientID))
+	add("project_id", formatInt(pc.ProjectID))
+	add("service_id", formatInt(pc.ServiceID))
+	add("profile", pc.Profile)
+	if pc.Billable != nil {
+		add("billable", strconv.FormatBool(*pc.Billable))
+	}
+	add("rate", pc.Rate)
+	add("currency", pc.Currency)
+	add("note_template", pc.NoteTemplate)
+	add("tags", strings.Join(pc.Tags, ", "))
+	add("rounding", pc.Rounding.String())
+	return out
+}
+
+// validate checks the values read from project files and normalizes the
+// rate and currency.
+func (pc *ProjectConfig) validate() error {
+	check := func(key string, err error) error {
+		if err != nil {
+			return fmt.Errorf("invalid %s in %s: %w", key, strings.TrimPrefix(pc.Source(key), "project file "), err)
+		}
+		return nil
+	}
+	var err error
+	if pc.Rate != "" {
+		pc.Rate, err = normalizeRate(pc.Rate)
+		if err := check("rate", err); err != nil {
+			return err
+		}
+	}
+	pc.Currency, err = normalizeCurrency(pc.Currency)
+	if err := check("currency", err); err != nil {
+		return err
+	}
+	if pc.NoteTemplate != "" {
+		_, err := template.New("note").Parse(pc.NoteTemplate)
+		if err := check("note_template", err); err != nil {
+			return err
+		}
+	}
+	_, err = pc.Rounding.Rule()
+	return check("rounding", err)
+}
+
+// field returns a pointer to the ID field for key.
+func (pc *ProjectConfig) field(key string) *int {
+	switch key {
+	case "client_id":
+		return &pc.ClientID
+	case "project_id":
+		return &pc.ProjectID
+	case "service_id":
+		return &pc.ServiceID
+	}
+	return nil
 }
 
 // LoadProjectConfig reads a .freshtime.json from the given directory.
 func LoadProjectConfig(dir string) (*ProjectConfig, error) {
-	data, err := os.ReadFile(filepath.Join(dir, ProjectConfigFile))
+	path := filepath.Join(dir, ProjectConfigFile)
+	doc, err := readProjectFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w in %s", ErrNoProjectConfig, dir)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("no %s found in %s", ProjectConfigFile, dir)
+		return nil, err
 	}
-	var pc ProjectConfig
-	if err := json.Unmarshal(data, &pc); err != nil {
+	return mergeProjectFiles([]string{path}, []map[string]json.RawMessage{doc})
+}
+
+// FindProjectConfig looks for .freshtime.json in dir and each of its
+// parents, the way git finds .gitignore files. The search stops at a git
+// repository root, a file with "root": true, or the filesystem root. Keys
+// set in files nearer dir override those set further up; other keys are
+// inherited.
+func FindProjectConfig(dir string) (*ProjectConfig, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		paths []string
+		docs  []map[string]json.RawMessage
+	)
+	for d := dir; ; {
+		path := filepath.Join(d, ProjectConfigFile)
+		doc, err := readProjectFile(path)
+		switch {
+		case err == nil:
+			paths = append([]string{path}, paths...)
+			docs = append([]map[string]json.RawMessage{doc}, docs...)
+			if string(doc["root"]) == "true" {
+				return mergeProjectFiles(paths, docs)
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w in %s or its parents", ErrNoProjectConfig, dir)
+	}
+	return mergeProjectFiles(paths, docs)
+}
+
+// readProjectFile reads and decodes the .freshtime.json at path.
+func readProjectFile(path string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// mergeProjectFiles overlays the decoded files docs, read from paths,
+// outermost first, and decodes the result.
+func mergeProjectFiles(paths []string, docs []map[string]json.RawMessage) (*ProjectConfig, error) {
+	merged := make(map[string]json.RawMessage)
+	sources := make(map[string]string)
+	for i, doc := range docs {
+		for key, value := range doc {
+			merged[key] = value
+			sources[key] = "project file " + paths[i]
+		}
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	pc := &ProjectConfig{}
+	if err := json.Unmarshal(data, pc); err != nil {
 		return nil, fmt.Errorf("invalid %s: %w", ProjectConfigFile, err)
 	}
-	return &pc, nil
+	pc.paths = paths
+	pc.sources = sources
+	if err := pc.validate(); err != nil {
+		return nil, err
+	}
+	return pc, nil
 }
 
-// LoadProjectConfigFromCwd reads .freshtime.json from the current working directory.
+// LoadProjectConfigFromCwd finds the .freshtime.json files that apply to the
+// current working directory; see FindProjectConfig.
 func LoadProjectConfigFromCwd() (*ProjectConfig, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, err
 	}
-	return LoadProjectConfig(cwd)
+	return FindProjectConfig(cwd)
+}
+
+// LoadProjectDefaults returns the project defaults for the current
+// directory: the .freshtime.json files that apply to it, if any, overridden
+// by FRESHTIME_CLIENT_ID, FRESHTIME_PROJECT_ID and FRESHTIME_SERVICE_ID.
+// It returns an empty config when neither is present.
+func LoadProjectDefaults() (*ProjectConfig, error) {
+	pc, err := LoadProjectConfigFromCwd()
+	if errors.Is(err, ErrNoProjectConfig) {
+		pc, err = &ProjectConfig{sources: make(map[string]string)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range projectEnv {
+		v := os.Getenv(e.env)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid env %s: must be a positive integer", e.env)
+		}
+		*pc.field(e.key) = id
+		pc.sources[e.key] = "env " + e.env
+	}
+	return pc, nil
 }
 
 // SaveProjectConfig writes a .freshtime.json to the given directory.
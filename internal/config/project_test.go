@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hev/freshtime/internal/testutil"
+)
+
+func TestFindProjectConfig(t *testing.T) {
+	testutil.SetHome(t)
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := filepath.Join(repo, "src", "pkg")
+	if err := os.MkdirAll(pkg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProjectConfig(repo, &ProjectConfig{ClientID: 1, ProjectID: 2, Profile: "work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A subdirectory without its own file uses the one at the repo root.
+	pc, err := FindProjectConfig(pkg)
+	if err != nil {
+		t.Fatalf("FindProjectConfig failed: %v", err)
+	}
+	rootFile := filepath.Join(repo, ProjectConfigFile)
+	if pc.ClientID != 1 || !slices.Equal(pc.Files(), []string{rootFile}) {
+		t.Errorf("got %+v from %v, want client 1 from %s", pc, pc.Files(), rootFile)
+	}
+
+	// A nested file overrides the keys it sets and inherits the rest.
+	if err := SaveProjectConfig(filepath.Join(repo, "src"), &ProjectConfig{ProjectID: 3}); err != nil {
+		t.Fatal(err)
+	}
+	pc, err = FindProjectConfig(pkg)
+	if err != nil {
+		t.Fatalf("FindProjectConfig failed: %v", err)
+	}
+	nested := filepath.Join(repo, "src", ProjectConfigFile)
+	if pc.ClientID != 1 || pc.ProjectID != 3 || pc.Profile != "work" {
+		t.Errorf("merged: got %+v, want client 1, project 3, profile work", pc)
+	}
+	if got := pc.Source("project_id"); got != "project file "+nested {
+		t.Errorf("project_id source: got %q", got)
+	}
+	if got := pc.Source("client_id"); got != "project file "+rootFile {
+		t.Errorf("client_id source: got %q", got)
+	}
+
+	// "root": true stops inheritance.
+	if err := SaveProjectConfig(filepath.Join(repo, "src"), &ProjectConfig{ProjectID: 3, Root: true}); err != nil {
+		t.Fatal(err)
+	}
+	pc, err = FindProjectConfig(pkg)
+	if err != nil {
+		t.Fatalf("FindProjectConfig failed: %v", err)
+	}
+	if pc.ClientID != 0 || pc.ProjectID != 3 || !slices.Equal(pc.Files(), []string{nested}) {
+		t.Errorf("root file: got %+v from %v", pc, pc.Files())
+	}
+}
+
+func TestFindProjectConfigStopsAtGitRoot(t *testing.T) {
+	testutil.SetHome(t)
+	outer := t.TempDir()
+	if err := SaveProjectConfig(outer, &ProjectConfig{ClientID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(outer, "repo")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindProjectConfig(repo); !errors.Is(err, ErrNoProjectConfig) {
+		t.Errorf("expected ErrNoProjectConfig, got %v", err)
+	}
+}
+
+func TestProjectConfigValidation(t *testing.T) {
+	testutil.SetHome(t)
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		body string
+		want string
+	}{
+		{`{"currency": "eur", "rate": " 95.5 "}`, ""},
+		{`{"currency": "EURO"}`, "invalid currency"},
+		{`{"rate": "lots"}`, "invalid rate"},
+		{`{"note_template": "{{.Message"}`, "invalid note_template"},
+		{`{"rounding": {"increment": "7s"}}`, "invalid rounding"},
+		{`{"rounding": {"increment": "15m", "mode": "sideways"}}`, "invalid rounding"},
+		{`{"rounding": {"increment": "6m", "minimum": "90s"}}`, "invalid rounding"},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, ProjectConfigFile), []byte(tt.body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		pc, err := FindProjectConfig(dir)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.body, err)
+		case tt.want == "" && (pc.Currency != "EUR" || pc.Rate != "95.5"):
+			t.Errorf("%s: got currency %q, rate %q; want normalized values", tt.body, pc.Currency, pc.Rate)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: got error %v, want %q", tt.body, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ProfileEnv selects the profile when --profile is not given.
+const ProfileEnv = "FRESHTIME_PROFILE"
+
+// Flags holds config values given as command-line flags, keyed by config
+// key (e.g. "business_id"). They take precedence over everything else.
+var Flags = map[string]string{}
+
+// override is a profile key that can be set from the environment or a flag.
+type override struct {
+	key  string
+	env  string
+	flag string // empty if there is no flag
+}
+
+// overrides lists the profile keys that can be overridden. Tokens have no
+// flags so they never show up in process listings or shell history.
+var overrides = []override{
+	{key: "access_token", env: "FRESHTIME_ACCESS_TOKEN"},
+	{key: "refresh_token", env: "FRESHTIME_REFRESH_TOKEN"},
+	{key: "account_id", env: "FRESHTIME_ACCOUNT_ID", flag: "account-id"},
+	{key: "business_id", env: "FRESHTIME_BUSINESS_ID", flag: "business-id"},
+	{key: "default_currency", env: "FRESHTIME_CURRENCY"},
+}
+
+// lookup returns the override value for o and where it came from, or an
+// empty source if o is not overridden.
+func (o override) lookup() (value, source string) {
+	if v := Flags[o.key]; v != "" {
+		return v, "flag --" + o.flag
+	}
+	if v := os.Getenv(o.env); v != "" {
+		return v, "env " + o.env
+	}
+	return "", ""
+}
+
+// tokenOverridden reports whether the access token comes from a flag or the
+// environment rather than the config file.
+func tokenOverridden() bool {
+	for _, o := range overrides {
+		if o.key == "access_token" {
+			_, src := o.lookup()
+			return src != ""
+		}
+	}
+	return false
+}
+
+// activeSource returns the active profile name and where the choice came
+// from: --profile, then FRESHTIME_PROFILE, then the project file, then the
+// config file's current profile.
+func (f *File) activeSource() (name, source string) {
+	if Profile != "" {
+		return Profile, "flag --profile"
+	}
+	if env := os.Getenv(ProfileEnv); env != "" {
+		return env, "env " + ProfileEnv
+	}
+	if pc, err := LoadProjectConfigFromCwd(); err == nil && pc.Profile != "" {
+		return pc.Profile, pc.Source("profile")
+	}
+	if f.CurrentProfile != "" {
+		return f.CurrentProfile, "current_profile in " + Path()
+	}
+	return DefaultProfile, "default"
+}
+
+// applyOverrides sets flag and environment values on cfg, remembering the
+// stored values so Save does not persist the overrides.
+func (cfg *Config) applyOverrides() error {
+	stored := *cfg
+	for _, o := range overrides {
+		v, src := o.lookup()
+		if src == "" {
+			continue
+		}
+		if cfg.sources == nil {
+			cfg.sources = make(map[string]string)
+			cfg.stored = &stored
+		}
+		s, _ := LookupSetting(o.key)
+		if err := s.set(nil, cfg, v); err != nil {
+			return fmt.Errorf("invalid %s: %w", src, err)
+		}
+		cfg.sources[o.key] = src
+	}
+
+	if src := cfg.sources["access_token"]; src != "" {
+		// Metadata of the stored token does not describe this one, and the
+		// stored refresh token belongs to a different grant.
+		cfg.TokenExpiresAt = time.Time{}
+		cfg.TokenCreatedAt = time.Time{}
+		cfg.TokenScope = ""
+		if cfg.sources["refresh_token"] == "" {
+			cfg.RefreshToken = ""
+		}
+	}
+	return nil
+}
+
+// Source returns where the value of key came from when a flag or the
+// environment overrides it, or "" when it is the stored value.
+func (cfg *Config) Source(key string) string {
+	return cfg.sources[key]
+}
+
+// persistable returns the config to write for cfg: overridden values that
+// were not changed since Load are replaced by the stored ones, and tokens
+// from the environment or flags are never written.
+func (cfg *Config) persistable() *Config {
+	if cfg.stored == nil {
+		return cfg
+	}
+	out := *cfg
+	out.stored, out.sources = nil, nil
+	if cfg.sources["access_token"] != "" || cfg.sources["refresh_token"] != "" {
+		out.setCredentials(cfg.stored.Credentials())
+	}
+	for _, o := range overrides {
+		if cfg.sources[o.key] == "" || o.key == "access_token" || o.key == "refresh_token" {
+			continue
+		}
+		s, _ := LookupSetting(o.key)
+		if v, _ := o.lookup(); normalizeOverride(s, v) == s.get(nil, cfg) {
+			s.set(nil, &out, s.get(nil, cfg.stored))
+		}
+	}
+	return &out
+}
+
+// normalizeOverride returns v the way s stores it.
+func normalizeOverride(s *Setting, v string) string {
+	var c Config
+	if err := s.set(nil, &c, v); err != nil {
+		return v
+	}
+	return s.get(nil, &c)
+}
+
+// Resolved is an effective config value and where it came from.
+type Resolved struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// Resolve returns the effective value of every setting that applies to the
+// current command, and where each came from. Precedence is flag, then
+// environment, then project file, then user config.
+func Resolve() ([]Resolved, error) {
+	pathSource := "default"
+	switch {
+	case ConfigFile != "":
+		pathSource = "flag --config"
+	case os.Getenv(ConfigEnv) != "":
+		pathSource = "env " + ConfigEnv
+	}
+	out := []Resolved{{Key: "config", Value: Path(), Source: pathSource}}
+
+	f, err := LoadFile()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	name, src := f.activeSource()
+	out = append(out, Resolved{Key: "profile", Value: name, Source: src})
+
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	stored := "profile " + name
+	tokenSource := stored
+	if f.CredentialStore != "" && f.CredentialStore != StoreInline {
+		tokenSource = f.CredentialStore + " credential store"
+	}
+	for _, s := range settings {
+		if s.Global {
+			continue
+		}
+		v := s.get(nil, cfg)
+		if v == "" {
+			continue
+		}
+		src := cfg.sources[s.Key]
+		switch {
+		case src != "":
+		case s.Secret || s.Key == "token_expires_at" || s.Key == "token_scope":
+			src = tokenSource
+		default:
+			src = stored
+		}
+		out = append(out, Resolved{Key: s.Key, Value: v, Source: src, Secret: s.Secret})
+	}
+	for _, e := range clientEntries(cfg) {
+		out = append(out, Resolved{Key: e.Key, Value: e.Value, Source: stored})
+	}
+
+	pc, err := LoadProjectDefaults()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range pc.Values() {
+		out = append(out, Resolved{Key: e.Key, Value: e.Value, Source: pc.Source(e.Key)})
+	}
+	return out, nil
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hev/freshtime/internal/testutil"
+)
+
+func TestLoadOverrides(t *testing.T) {
+	testutil.SetHome(t)
+	t.Cleanup(func() { Flags = map[string]string{} })
+
+	if err := Save(&Config{AccessToken: "stored-token", RefreshToken: "stored-refresh", AccountID: "acct", BusinessID: 1}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	t.Setenv("FRESHTIME_ACCESS_TOKEN", "env-token")
+	t.Setenv("FRESHTIME_BUSINESS_ID", "2")
+	Flags["business_id"] = "3"
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.AccessToken != "env-token" {
+		t.Errorf("AccessToken: got %q, want env-token", cfg.AccessToken)
+	}
+	if cfg.RefreshToken != "" {
+		t.Errorf("RefreshToken: got %q, want it cleared with an overridden access token", cfg.RefreshToken)
+	}
+	if cfg.BusinessID != 3 {
+		t.Errorf("BusinessID: got %d, want the flag's 3", cfg.BusinessID)
+	}
+	if cfg.AccountID != "acct" {
+		t.Errorf("AccountID: got %q, want acct", cfg.AccountID)
+	}
+
+	// Saving must not write the overrides, but must keep real changes.
+	cfg.DefaultCurrency = "EUR"
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	os.Unsetenv("FRESHTIME_ACCESS_TOKEN")
+	os.Unsetenv("FRESHTIME_BUSINESS_ID")
+	Flags = map[string]string{}
+	stored, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if stored.AccessToken != "stored-token" || stored.RefreshToken != "stored-refresh" {
+		t.Errorf("tokens: got %q/%q, want the stored ones", stored.AccessToken, stored.RefreshToken)
+	}
+	if stored.BusinessID != 1 {
+		t.Errorf("BusinessID: got %d, want 1", stored.BusinessID)
+	}
+	if stored.DefaultCurrency != "EUR" {
+		t.Errorf("DefaultCurrency: got %q, want EUR", stored.DefaultCurrency)
+	}
+}
+
+func TestLoadWithoutConfigFile(t *testing.T) {
+	testutil.SetHome(t)
+	t.Setenv("FRESHTIME_ACCESS_TOKEN", "env-token")
+	t.Setenv("FRESHTIME_ACCOUNT_ID", "acct")
+	t.Setenv("FRESHTIME_BUSINESS_ID", "7")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.AccessToken != "env-token" || cfg.AccountID != "acct" || cfg.BusinessID != 7 {
+		t.Errorf("got %+v", cfg)
+	}
+
+	t.Setenv("FRESHTIME_BUSINESS_ID", "seven")
+	if _, err := Load(); err == nil {
+		t.Error("expected an error for a non-numeric FRESHTIME_BUSINESS_ID")
+	}
+}
+
+func TestConfigPathOverride(t *testing.T) {
+	testutil.SetHome(t)
+	alt := filepath.Join(t.TempDir(), "alt.json")
+	t.Setenv(ConfigEnv, alt)
+	if Path() != alt {
+		t.Errorf("Path: got %q, want %q", Path(), alt)
+	}
+
+	flag := filepath.Join(t.TempDir(), "flag.json")
+	ConfigFile = flag
+	t.Cleanup(func() { ConfigFile = "" })
+	if Path() != flag {
+		t.Errorf("Path: got %q, want the flag's %q", Path(), flag)
+	}
+}
+
+func TestResolveSources(t *testing.T) {
+	testutil.SetHome(t)
+	if err := Save(&Config{AccessToken: "stored-token", AccountID: "acct", BusinessID: 1}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := SaveProjectConfig(dir, &ProjectConfig{ClientID: 5, ProjectID: 6}); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	t.Setenv("FRESHTIME_BUSINESS_ID", "2")
+	t.Setenv("FRESHTIME_PROJECT_ID", "9")
+
+	values, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	got := make(map[string]Resolved)
+	for _, r := range values {
+		got[r.Key] = r
+	}
+	for key, want := range map[string]Resolved{
+		"profile":      {Value: DefaultProfile, Source: "current_profile in " + Path()},
+		"business_id":  {Value: "2", Source: "env FRESHTIME_BUSINESS_ID"},
+		"account_id":   {Value: "acct", Source: "profile default"},
+		"access_token": {Value: "stored-token", Source: "file credential store"},
+		"client_id":    {Value: "5", Source: "project file " + filepath.Join(dir, ProjectConfigFile)},
+		"project_id":   {Value: "9", Source: "env FRESHTIME_PROJECT_ID"},
+	} {
+		if r := got[key]; r.Value != want.Value || r.Source != want.Source {
+			t.Errorf("%s: got %q from %q, want %q from %q", key, r.Value, r.Source, want.Value, want.Source)
+		}
+	}
+}
+
+func TestProjectProfile(t *testing.T) {
+	testutil.SetHome(t)
+	for _, name := range []string{"work", "client"} {
+		if err := Save(&Config{Name: name, AccessToken: name + "-token"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := t.TempDir()
+	if err := SaveProjectConfig(dir, &ProjectConfig{Profile: "client"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Name != "client" {
+		t.Errorf("profile: got %q, want the project file's client", cfg.Name)
+	}
+
+	t.Setenv(ProfileEnv, "work")
+	if cfg, err = Load(); err != nil || cfg.Name != "work" {
+		t.Errorf("profile: got %v, %v; want FRESHTIME_PROFILE's work", cfg, err)
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hev/freshtime/internal/duration"
+)
+
+// Rounding is a duration rounding rule as written in config files, e.g.
+// {"increment": "6m", "mode": "up", "minimum": "15m"}.
+type Rounding struct {
+	Increment string `json:"increment,omitempty"`
+	Mode      string `json:"mode,omitempty"` // nearest (default), up or down
+	Minimum   string `json:"minimum,omitempty"`
+}
+
+// Rule parses r. A nil r is the zero Rounding, which rounds nothing.
+func (r *Rounding) Rule() (duration.Rounding, error) {
+	if r == nil {
+		return duration.Rounding{}, nil
+	}
+	inc, err := parseMinutes(r.Increment)
+	if err != nil {
+		return duration.Rounding{}, fmt.Errorf("increment %w", err)
+	}
+	mode, err := duration.ParseMode(r.Mode)
+	if err != nil {
+		return duration.Rounding{}, err
+	}
+	minimum, err := parseMinutes(r.Minimum)
+	if err != nil {
+		return duration.Rounding{}, fmt.Errorf("minimum %w", err)
+	}
+	return duration.Rounding{Increment: inc, Mode: mode, Minimum: minimum}, nil
+}
+
+// String describes r for display, or returns "" when r is unset or
+// invalid.
+func (r *Rounding) String() string {
+	rule, err := r.Rule()
+	if err != nil || rule.IsZero() {
+		return ""
+	}
+	return rule.String()
+}
+
+// field returns a pointer to the field of r named by a config key suffix.
+func (r *Rounding) field(name string) *string {
+	switch name {
+	case "increment":
+		return &r.Increment
+	case "mode":
+		return &r.Mode
+	case "minimum":
+		return &r.Minimum
+	}
+	return nil
+}
+
+// parseMinutes parses an increment or minimum, which must be whole minutes
+// between 1m and 24h. An empty string is zero.
+func parseMinutes(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < time.Minute || d > 24*time.Hour || d%time.Minute != 0 {
+		return 0, fmt.Errorf("%q must be whole minutes between 1m and 24h", v)
+	}
+	return d, nil
+}
+
+// RoundingFor returns the rounding rule for time logged or invoiced for
+// clientID, and where it came from: the rule in pc if pc is for that client,
+// then the client's rule in the profile, then the profile's. pc may be nil.
+// This is the order rates are resolved in, so log, stop and invoice agree.
+func (c *Config) RoundingFor(clientID int, pc *ProjectConfig) (*Rounding, string) {
+	if pc != nil && pc.Rounding != nil && pc.ClientID == clientID {
+		return pc.Rounding, pc.Source("rounding")
+	}
+	if r := c.ClientRounding[strconv.Itoa(clientID)]; r != nil {
+		return r, clientRoundingPrefix + strconv.Itoa(clientID)
+	}
+	if c.Rounding != nil {
+		return c.Rounding, "rounding"
+	}
+	return nil, ""
+}
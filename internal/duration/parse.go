@@ -0,0 +1,85 @@
+package duration
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Max is the longest duration Parse accepts. Anything longer is almost
+// certainly a typo, such as minutes given as hours.
+const Max = 24 * time.Hour
+
+var (
+	clockRe  = regexp.MustCompile(`^(\d+):([0-5]\d)$`)
+	numberRe = regexp.MustCompile(`^(\d+(?:\.\d*)?|\.\d+)$`)
+	partRe   = regexp.MustCompile(`^(\d+(?:\.\d*)?|\.\d+)\s*([a-z]+)`)
+)
+
+var units = map[string]time.Duration{
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+}
+
+// Parse parses a duration as people type it when logging time:
+//
+//	2h, 1h30m, 2h 15m, 45min, 1.5 hours   hours and minutes with units
+//	1:30                                  hours and minutes on a clock
+//	90                                    a whole number is minutes
+//	1.5, 0.25                             a decimal is hours
+//
+// The result is rounded to the second. Durations of zero or longer than
+// Max are rejected.
+func Parse(s string) (time.Duration, error) {
+	in := strings.ToLower(strings.TrimSpace(s))
+	d, ok := parse(in)
+	switch {
+	case !ok:
+		return 0, fmt.Errorf("invalid duration %q (expected e.g. 1h30m, 1.5h, 90m or 1:30)", s)
+	case d <= 0:
+		return 0, fmt.Errorf("duration %q must be more than zero", s)
+	case d > Max:
+		return 0, fmt.Errorf("duration %q is longer than %s", s, Format(Max))
+	}
+	return d, nil
+}
+
+func parse(s string) (time.Duration, bool) {
+	if m := clockRe.FindStringSubmatch(s); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		min, _ := strconv.Atoi(m[2])
+		return time.Duration(h)*time.Hour + time.Duration(min)*time.Minute, true
+	}
+	if numberRe.MatchString(s) {
+		n, _ := strconv.ParseFloat(s, 64)
+		unit := time.Minute
+		if strings.Contains(s, ".") {
+			unit = time.Hour
+		}
+		return scale(n, unit), true
+	}
+
+	var total time.Duration
+	seen := map[time.Duration]bool{}
+	for rest := s; rest != ""; rest = strings.TrimSpace(rest) {
+		m := partRe.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, false
+		}
+		unit, ok := units[m[2]]
+		if !ok || seen[unit] {
+			return 0, false
+		}
+		seen[unit] = true
+		n, _ := strconv.ParseFloat(m[1], 64)
+		total += scale(n, unit)
+		rest = rest[len(m[0]):]
+	}
+	return total, len(seen) > 0
+}
+
+func scale(n float64, unit time.Duration) time.Duration {
+	return time.Duration(n * float64(unit)).Round(time.Second)
+}
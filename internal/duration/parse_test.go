@@ -0,0 +1,50 @@
+package duration
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"2h":          2 * time.Hour,
+		"30m":         30 * time.Minute,
+		"1h30m":       90 * time.Minute,
+		"2h 15m":      135 * time.Minute,
+		"45min":       45 * time.Minute,
+		"1.5h":        90 * time.Minute,
+		"1.5 hours":   90 * time.Minute,
+		"1 hr 5 mins": 65 * time.Minute,
+		"1:30":        90 * time.Minute,
+		"0:05":        5 * time.Minute,
+		"90":          90 * time.Minute,
+		"0.25":        15 * time.Minute,
+		".5":          30 * time.Minute,
+		" 2H ":        2 * time.Hour,
+		"24h":         24 * time.Hour,
+	} {
+		if got, err := Parse(in); err != nil || got != want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+
+	for in, want := range map[string]string{
+		"":      "invalid duration",
+		"abc":   "invalid duration",
+		"2x":    "invalid duration",
+		"1h1h":  "invalid duration",
+		"1:75":  "invalid duration",
+		"-1h":   "invalid duration",
+		"1h30":  "invalid duration",
+		"0m":    "more than zero",
+		"0":     "more than zero",
+		"0:00":  "more than zero",
+		"25h":   "longer than 24h",
+		"1500m": "longer than 24h",
+	} {
+		if _, err := Parse(in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q): got error %v, want %q", in, err, want)
+		}
+	}
+}
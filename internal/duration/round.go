@@ -0,0 +1,104 @@
+// Package duration parses, rounds and formats the durations of time
+// entries.
+package duration
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Mode is the direction a Rounding rounds in.
+type Mode string
+
+const (
+	Nearest Mode = "nearest"
+	Up      Mode = "up"
+	Down    Mode = "down"
+)
+
+// ParseMode parses "nearest", "up" or "down". An empty string is Nearest.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", Nearest:
+		return Nearest, nil
+	case Up, Down:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("rounding mode %q must be nearest, up or down", s)
+}
+
+// Rounding rounds durations to a multiple of Increment and raises them to
+// at least Minimum. The zero Rounding leaves durations unchanged.
+type Rounding struct {
+	Increment time.Duration
+	Mode      Mode
+	Minimum   time.Duration
+}
+
+// IsZero reports whether r leaves durations unchanged.
+func (r Rounding) IsZero() bool {
+	return r.Increment <= 0 && r.Minimum <= 0
+}
+
+// Round returns seconds rounded to a multiple of r.Increment. A positive
+// duration is never rounded below one increment, so no work is logged as
+// zero, nor below r.Minimum, which is itself rounded up to an increment.
+// Rounding a rounded duration again leaves it unchanged.
+func (r Rounding) Round(seconds int) int {
+	if seconds <= 0 {
+		return seconds
+	}
+	inc := int(r.Increment / time.Second)
+	if inc > 0 {
+		var n int
+		switch r.Mode {
+		case Up:
+			n = (seconds + inc - 1) / inc
+		case Down:
+			n = seconds / inc
+		default:
+			n = (seconds + inc/2) / inc
+		}
+		seconds = max(n, 1) * inc
+	}
+	if minimum := int(r.Minimum / time.Second); seconds < minimum {
+		seconds = minimum
+		if inc > 0 {
+			seconds = (minimum + inc - 1) / inc * inc
+		}
+	}
+	return seconds
+}
+
+// String describes r, e.g. "15m up" or "6m up, minimum 15m".
+func (r Rounding) String() string {
+	var parts []string
+	if r.Increment > 0 {
+		mode := r.Mode
+		if mode == "" {
+			mode = Nearest
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", Format(r.Increment), mode))
+	}
+	if r.Minimum > 0 {
+		parts = append(parts, "minimum "+Format(r.Minimum))
+	}
+	if r.IsZero() {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Format formats d in hours and minutes, e.g. "1h30m", "15m" or "2h".
+func Format(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%dm", m)
+	case m == 0:
+		return fmt.Sprintf("%dh", h)
+	}
+	return fmt.Sprintf("%dh%dm", h, m)
+}
@@ -0,0 +1,77 @@
+package duration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRound(t *testing.T) {
+	quarter := 15 * time.Minute
+	tests := []struct {
+		rounding Rounding
+		seconds  int
+		want     int
+	}{
+		{Rounding{}, 1234, 1234},
+		{Rounding{Increment: quarter}, 7 * 60, 15 * 60},
+		{Rounding{Increment: quarter}, 22*60 + 29, 15 * 60},
+		{Rounding{Increment: quarter}, 22*60 + 30, 30 * 60},
+		{Rounding{Increment: quarter, Mode: Up}, 16 * 60, 30 * 60},
+		{Rounding{Increment: quarter, Mode: Up}, 30 * 60, 30 * 60},
+		{Rounding{Increment: quarter, Mode: Down}, 29 * 60, 15 * 60},
+		{Rounding{Increment: quarter, Mode: Down}, 60, 15 * 60}, // never below one increment
+		{Rounding{Increment: quarter, Mode: Down}, 0, 0},
+		{Rounding{Minimum: quarter}, 5 * 60, 15 * 60},
+		{Rounding{Minimum: quarter}, 20*60 + 7, 20*60 + 7},
+		{Rounding{Increment: 6 * time.Minute, Mode: Up, Minimum: quarter}, 7 * 60, 18 * 60},
+		{Rounding{Increment: 6 * time.Minute, Mode: Down, Minimum: quarter}, 16 * 60, 18 * 60},
+		{Rounding{Increment: 5 * time.Minute, Minimum: quarter}, 60, 15 * 60},
+		{Rounding{Increment: 6 * time.Minute, Mode: Up, Minimum: quarter}, 16 * 60, 18 * 60},
+		{Rounding{Minimum: quarter}, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.rounding.Round(tt.seconds); got != tt.want {
+			t.Errorf("%v.Round(%d) = %d, want %d", tt.rounding, tt.seconds, got, tt.want)
+		}
+		if got := tt.rounding.Round(tt.want); got != tt.want {
+			t.Errorf("%v.Round(%d) = %d, want it unchanged", tt.rounding, tt.want, got)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": Nearest, "nearest": Nearest, "up": Up, "down": Down} {
+		if got, err := ParseMode(in); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseMode("sideways"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		15 * time.Minute: "15m",
+		2 * time.Hour:    "2h",
+		90 * time.Minute: "1h30m",
+		0:                "0m",
+	} {
+		if got := Format(d); got != want {
+			t.Errorf("Format(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestRoundingString(t *testing.T) {
+	for r, want := range map[Rounding]string{
+		{}:                            "none",
+		{Increment: 15 * time.Minute}: "15m nearest",
+		{Increment: 6 * time.Minute, Mode: Up, Minimum: 15 * time.Minute}: "6m up, minimum 15m",
+		{Minimum: time.Hour}: "minimum 1h",
+	} {
+		if got := r.String(); got != want {
+			t.Errorf("%#v.String() = %q, want %q", r, got, want)
+		}
+	}
+}
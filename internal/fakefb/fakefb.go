@@ -0,0 +1,705 @@
+// Package fakefb implements an in-memory FreshBooks API for tests and demos.
+//
+// It covers the endpoints freshtime uses: identity, OAuth token refresh and
+// revocation, clients, projects, services, time entries, invoices and share
+// links. State is kept in memory and mutated by create/update/delete calls, so
+// complete workflows can be exercised against it.
+package fakefb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client is a FreshBooks client record.
+type Client struct {
+	ID           int    `json:"id"`
+	Organization string `json:"organization"`
+	FName        string `json:"fname"`
+	LName        string `json:"lname"`
+	Email        string `json:"email"`
+}
+
+// Project is a FreshBooks project.
+type Project struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	ClientID int    `json:"client_id"`
+}
+
+// Service is a FreshBooks service.
+type Service struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// TimeEntry is a FreshBooks time entry.
+type TimeEntry struct {
+	ID             int    `json:"id"`
+	ClientID       int    `json:"client_id"`
+	ProjectID      int    `json:"project_id"`
+	ServiceID      int    `json:"service_id"`
+	Duration       int    `json:"duration"`
+	StartedAt      string `json:"started_at"`
+	LocalStartedAt string `json:"local_started_at"`
+	Note           string `json:"note"`
+	Billable       bool   `json:"billable"`
+	Billed         bool   `json:"billed"`
+	IsLogged       bool   `json:"is_logged"`
+}
+
+// Amount is a monetary amount with currency code.
+type Amount struct {
+	Amount string `json:"amount"`
+	Code   string `json:"code"`
+}
+
+// InvoiceLine is a line item on an invoice.
+type InvoiceLine struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Qty         string `json:"qty"`
+	UnitCost    Amount `json:"unit_cost"`
+}
+
+// Invoice is a FreshBooks invoice.
+type Invoice struct {
+	InvoiceID     int           `json:"invoiceid"`
+	InvoiceNumber string        `json:"invoice_number"`
+	CustomerID    int           `json:"customerid"`
+	CreateDate    string        `json:"create_date"`
+	Lines         []InvoiceLine `json:"lines"`
+	Status        int           `json:"status"`
+	Notes         string        `json:"notes"`
+	Amount        Amount        `json:"amount"`
+	V3Status      string        `json:"v3_status"`
+}
+
+// Server is an in-memory FreshBooks API. It implements http.Handler and is
+// usually wrapped in an httptest.Server or a plain http.Server.
+type Server struct {
+	AccountID    string
+	BusinessID   int
+	BusinessName string
+	// Token, if set, is the only bearer token accepted. Otherwise any
+	// non-empty bearer token is accepted.
+	Token string
+
+	mu       sync.Mutex
+	nextID   int
+	clients  []Client
+	projects []Project
+	services []Service
+	entries  []TimeEntry
+	invoices []Invoice
+	revoked  map[string]bool
+	mux      *http.ServeMux
+}
+
+// New returns an empty fake server.
+func New() *Server {
+	s := &Server{
+		AccountID:    "fake-account",
+		BusinessID:   1,
+		BusinessName: "Fake Consulting LLC",
+		nextID:       100,
+		revoked:      make(map[string]bool),
+	}
+	s.routes()
+	return s
+}
+
+// NewDemo returns a fake server seeded with sample clients, projects,
+// services and time entries for the current week.
+func NewDemo() *Server {
+	s := New()
+	acme := s.AddClient(Client{Organization: "Acme Corp"})
+	globex := s.AddClient(Client{Organization: "Globex Inc"})
+	s.AddClient(Client{FName: "Jane", LName: "Doe"})
+	web := s.AddProject(Project{ClientID: acme, Title: "Website Redesign"})
+	s.AddProject(Project{ClientID: globex, Title: "Data Migration"})
+	dev := s.AddService(Service{Name: "Development"})
+	s.AddService(Service{Name: "Consulting"})
+
+	now := time.Now()
+	monday := now.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+	for i, e := range []struct {
+		client, day, hours int
+		note               string
+	}{
+		{acme, 0, 3, "Homepage layout"},
+		{acme, 1, 2, "Navigation menu"},
+		{globex, 1, 4, "Schema mapping"},
+		{acme, 2, 5, "Accessibility fixes"},
+		{globex, 3, 1, "Status call"},
+	} {
+		start := time.Date(monday.Year(), monday.Month(), monday.Day()+e.day, 9+i%3, 0, 0, 0, time.UTC)
+		entry := TimeEntry{
+			ClientID:  e.client,
+			ServiceID: dev,
+			Duration:  e.hours * 3600,
+			StartedAt: start.Format("2006-01-02T15:04:05Z"),
+			Note:      e.note,
+			Billable:  true,
+		}
+		if e.client == acme {
+			entry.ProjectID = web
+		}
+		s.AddTimeEntry(entry)
+	}
+	return s
+}
+
+func (s *Server) id() int {
+	s.nextID++
+	return s.nextID
+}
+
+// AddClient stores c with a fresh ID and returns the ID.
+func (s *Server) AddClient(c Client) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.ID = s.id()
+	s.clients = append(s.clients, c)
+	return c.ID
+}
+
+// AddProject stores p with a fresh ID and returns the ID.
+func (s *Server) AddProject(p Project) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.ID = s.id()
+	s.projects = append(s.projects, p)
+	return p.ID
+}
+
+// AddService stores svc with a fresh ID and returns the ID.
+func (s *Server) AddService(svc Service) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svc.ID = s.id()
+	s.services = append(s.services, svc)
+	return svc.ID
+}
+
+// AddTimeEntry stores e with a fresh ID and returns the ID.
+func (s *Server) AddTimeEntry(e TimeEntry) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addTimeEntry(e).ID
+}
+
+func (s *Server) addTimeEntry(e TimeEntry) TimeEntry {
+	e.ID = s.id()
+	e.IsLogged = true
+	if e.LocalStartedAt == "" {
+		e.LocalStartedAt = strings.TrimSuffix(e.StartedAt, "Z")
+	}
+	s.entries = append(s.entries, e)
+	return e
+}
+
+// TimeEntries returns a snapshot of all stored time entries.
+func (s *Server) TimeEntries() []TimeEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.entries)
+}
+
+// Invoices returns a snapshot of all created invoices.
+func (s *Server) Invoices() []Invoice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.invoices)
+}
+
+// Revoked reports whether token has been revoked via the OAuth revoke endpoint.
+func (s *Server) Revoked(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revoked[token]
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /auth/oauth/token", s.handleToken)
+	mux.HandleFunc("POST /auth/oauth/revoke", s.handleRevoke)
+	mux.HandleFunc("GET /auth/api/v1/users/me", s.authed(s.handleMe))
+	mux.HandleFunc("GET /accounting/account/{account}/users/clients", s.authed(s.account(s.handleListClients)))
+	mux.HandleFunc("POST /accounting/account/{account}/invoices/invoices", s.authed(s.account(s.handleCreateInvoice)))
+	mux.HandleFunc("GET /accounting/account/{account}/invoices/invoices/{id}/share_link", s.authed(s.account(s.handleShareLink)))
+	mux.HandleFunc("GET /projects/business/{business}/projects", s.authed(s.business(s.handleListProjects)))
+	mux.HandleFunc("GET /comments/business/{business}/services", s.authed(s.business(s.handleListServices)))
+	mux.HandleFunc("GET /timetracking/business/{business}/time_entries", s.authed(s.business(s.handleListTimeEntries)))
+	mux.HandleFunc("POST /timetracking/business/{business}/time_entries", s.authed(s.business(s.handleCreateTimeEntry)))
+	mux.HandleFunc("GET /timetracking/business/{business}/time_entries/{id}", s.authed(s.business(s.handleGetTimeEntry)))
+	mux.HandleFunc("PUT /timetracking/business/{business}/time_entries/{id}", s.authed(s.business(s.handleUpdateTimeEntry)))
+	mux.HandleFunc("DELETE /timetracking/business/{business}/time_entries/{id}", s.authed(s.business(s.handleDeleteTimeEntry)))
+	s.mux = mux
+}
+
+// authed rejects requests without an acceptable bearer token.
+func (s *Server) authed(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || (s.Token != "" && token != s.Token) || s.Revoked(token) {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{
+				"error":             "unauthenticated",
+				"error_description": "The access token is invalid",
+			})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// account rejects requests for an account other than s.AccountID.
+func (s *Server) account(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("account") != s.AccountID {
+			accountingError(w, http.StatusNotFound, "", 1012, "The requested account does not exist.")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// business rejects requests for a business other than s.BusinessID.
+func (s *Server) business(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("business") != strconv.Itoa(s.BusinessID) {
+			writeJSON(w, http.StatusNotFound, map[string]any{"errno": 1012, "message": "business not found"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	var grant map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&grant); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_request"})
+		return
+	}
+	switch grant["grant_type"] {
+	case "refresh_token", "authorization_code":
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "unsupported_grant_type"})
+		return
+	}
+
+	s.mu.Lock()
+	n := s.id()
+	s.mu.Unlock()
+	token := fmt.Sprintf("fake-access-%d", n)
+	if s.Token != "" {
+		token = s.Token
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  token,
+		"refresh_token": fmt.Sprintf("fake-refresh-%d", n),
+		"token_type":    "Bearer",
+		"expires_in":    43200,
+		"scope":         "user:profile:read user:clients:read user:time_entries:read user:time_entries:write user:invoices:read user:invoices:write",
+		"created_at":    time.Now().Unix(),
+	})
+}
+
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["token"] == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_request"})
+		return
+	}
+	s.mu.Lock()
+	s.revoked[body["token"]] = true
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"response": map[string]any{
+			"id":         1,
+			"first_name": "Demo",
+			"last_name":  "User",
+			"email":      "demo@example.com",
+			"business_memberships": []any{
+				map[string]any{
+					"role": "owner",
+					"business": map[string]any{
+						"id":         s.BusinessID,
+						"name":       s.BusinessName,
+						"account_id": s.AccountID,
+					},
+				},
+			},
+		},
+	})
+}
+
+func (s *Server) handleListClients(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	clients := slices.Clone(s.clients)
+	s.mu.Unlock()
+
+	items, page, pages, perPage := paginate(clients, r)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"response": map[string]any{
+			"result": map[string]any{
+				"clients":  items,
+				"page":     page,
+				"pages":    pages,
+				"per_page": perPage,
+				"total":    len(clients),
+			},
+		},
+	})
+}
+
+func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
+	clientID, _ := strconv.Atoi(r.URL.Query().Get("client_id"))
+
+	s.mu.Lock()
+	var projects []Project
+	for _, p := range s.projects {
+		if clientID == 0 || p.ClientID == clientID {
+			projects = append(projects, p)
+		}
+	}
+	s.mu.Unlock()
+
+	writeMetaPage(w, r, "projects", projects)
+}
+
+func (s *Server) handleListServices(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	services := slices.Clone(s.services)
+	s.mu.Unlock()
+
+	writeMetaPage(w, r, "services", services)
+}
+
+func (s *Server) handleListTimeEntries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, err := parseFilterTime(q.Get("started_from"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"errno": 2001, "message": "invalid started_from"})
+		return
+	}
+	to, err := parseFilterTime(q.Get("started_to"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"errno": 2001, "message": "invalid started_to"})
+		return
+	}
+
+	s.mu.Lock()
+	var entries []TimeEntry
+	for _, e := range s.entries {
+		if matchesEntryFilters(e, q, from, to) {
+			entries = append(entries, e)
+		}
+	}
+	s.mu.Unlock()
+
+	writeMetaPage(w, r, "time_entries", entries)
+}
+
+func matchesEntryFilters(e TimeEntry, q url.Values, from, to time.Time) bool {
+	started, err := parseFilterTime(e.StartedAt)
+	if err != nil {
+		return false
+	}
+	if !from.IsZero() && started.Before(from) {
+		return false
+	}
+	if !to.IsZero() && started.After(to) {
+		return false
+	}
+	for key, val := range map[string]int{"client_id": e.ClientID, "project_id": e.ProjectID, "service_id": e.ServiceID} {
+		if v := q.Get(key); v != "" && v != strconv.Itoa(val) {
+			return false
+		}
+	}
+	for key, val := range map[string]bool{"billable": e.Billable, "billed": e.Billed} {
+		if v := q.Get(key); v != "" && v != strconv.FormatBool(val) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFilterTime parses the timestamp formats FreshBooks accepts in filters.
+// Timestamps without a zone are treated as UTC.
+func parseFilterTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15:04:05", v)
+}
+
+type timeEntryBody struct {
+	TimeEntry map[string]json.RawMessage `json:"time_entry"`
+}
+
+func (s *Server) handleCreateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	var body timeEntryBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TimeEntry == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"errno": 2001, "message": "time_entry is required"})
+		return
+	}
+
+	var e TimeEntry
+	if err := applyTimeEntry(&e, body.TimeEntry); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"errno": 2001, "message": err.Error()})
+		return
+	}
+	if errs := validateTimeEntry(e); len(errs) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"errno": 2001, "message": "Validation failed", "errors": errs})
+		return
+	}
+
+	s.mu.Lock()
+	e = s.addTimeEntry(e)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"time_entry": e})
+}
+
+func (s *Server) handleGetTimeEntry(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.entryIndex(id)
+	if i < 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"errno": 1012, "message": "time entry not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"time_entry": s.entries[i]})
+}
+
+func (s *Server) handleUpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	var body timeEntryBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TimeEntry == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"errno": 2001, "message": "time_entry is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.entryIndex(id)
+	if i < 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"errno": 1012, "message": "time entry not found"})
+		return
+	}
+	e := s.entries[i]
+	if err := applyTimeEntry(&e, body.TimeEntry); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"errno": 2001, "message": err.Error()})
+		return
+	}
+	if errs := validateTimeEntry(e); len(errs) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"errno": 2001, "message": "Validation failed", "errors": errs})
+		return
+	}
+	if _, ok := body.TimeEntry["local_started_at"]; !ok && e.StartedAt != s.entries[i].StartedAt {
+		e.LocalStartedAt = strings.TrimSuffix(e.StartedAt, "Z")
+	}
+	s.entries[i] = e
+	writeJSON(w, http.StatusOK, map[string]any{"time_entry": e})
+}
+
+func (s *Server) handleDeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.entryIndex(id)
+	if i < 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{"errno": 1012, "message": "time entry not found"})
+		return
+	}
+	s.entries = slices.Delete(s.entries, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) entryIndex(id int) int {
+	return slices.IndexFunc(s.entries, func(e TimeEntry) bool { return e.ID == id })
+}
+
+// applyTimeEntry copies the fields present in raw onto e.
+func applyTimeEntry(e *TimeEntry, raw map[string]json.RawMessage) error {
+	fields := map[string]any{
+		"client_id":        &e.ClientID,
+		"project_id":       &e.ProjectID,
+		"service_id":       &e.ServiceID,
+		"duration":         &e.Duration,
+		"started_at":       &e.StartedAt,
+		"local_started_at": &e.LocalStartedAt,
+		"note":             &e.Note,
+		"billable":         &e.Billable,
+		"billed":           &e.Billed,
+		"is_logged":        &e.IsLogged,
+	}
+	for key, val := range raw {
+		dst, ok := fields[key]
+		if !ok || string(val) == "null" {
+			continue
+		}
+		if err := json.Unmarshal(val, dst); err != nil {
+			return fmt.Errorf("invalid value for %s", key)
+		}
+	}
+	return nil
+}
+
+func validateTimeEntry(e TimeEntry) map[string][]string {
+	errs := make(map[string][]string)
+	if e.Duration <= 0 {
+		errs["duration"] = append(errs["duration"], "must be greater than 0")
+	}
+	if _, err := parseFilterTime(e.StartedAt); err != nil || e.StartedAt == "" {
+		errs["started_at"] = append(errs["started_at"], "must be a valid timestamp")
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+type invoiceBody struct {
+	Invoice Invoice `json:"invoice"`
+}
+
+func (s *Server) handleCreateInvoice(w http.ResponseWriter, r *http.Request) {
+	var body invoiceBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		accountingError(w, http.StatusBadRequest, "", 2001, "Invalid invoice payload.")
+		return
+	}
+	inv := body.Invoice
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !slices.ContainsFunc(s.clients, func(c Client) bool { return c.ID == inv.CustomerID }) {
+		accountingError(w, http.StatusUnprocessableEntity, "customerid", 1012, "Client not found.")
+		return
+	}
+
+	var total float64
+	code := "USD"
+	for _, line := range inv.Lines {
+		qty, err1 := strconv.ParseFloat(line.Qty, 64)
+		cost, err2 := strconv.ParseFloat(line.UnitCost.Amount, 64)
+		if err1 != nil || err2 != nil {
+			accountingError(w, http.StatusUnprocessableEntity, "lines", 2001, "Line quantity and unit cost must be numeric.")
+			return
+		}
+		total += qty * cost
+		if line.UnitCost.Code != "" {
+			code = line.UnitCost.Code
+		}
+	}
+
+	inv.InvoiceID = s.id()
+	inv.InvoiceNumber = fmt.Sprintf("%07d", len(s.invoices)+1)
+	inv.Amount = Amount{Amount: fmt.Sprintf("%.2f", total), Code: code}
+	inv.V3Status = "draft"
+	s.invoices = append(s.invoices, inv)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"response": map[string]any{
+			"result": map[string]any{"invoice": inv},
+		},
+	})
+}
+
+func (s *Server) handleShareLink(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	s.mu.Lock()
+	found := slices.ContainsFunc(s.invoices, func(inv Invoice) bool { return inv.InvoiceID == id })
+	s.mu.Unlock()
+	if !found {
+		accountingError(w, http.StatusNotFound, "invoiceid", 1012, "Invoice not found.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"response": map[string]any{
+			"result": map[string]any{
+				"share_link": fmt.Sprintf("https://my.freshbooks.example/view/%s/%d", s.AccountID, id),
+			},
+		},
+	})
+}
+
+// paginate slices items according to the page and per_page query parameters.
+func paginate[T any](items []T, r *http.Request) (page []T, n, pages, perPage int) {
+	q := r.URL.Query()
+	n, _ = strconv.Atoi(q.Get("page"))
+	if n < 1 {
+		n = 1
+	}
+	perPage, _ = strconv.Atoi(q.Get("per_page"))
+	if perPage < 1 {
+		perPage = 15
+	}
+	pages = max(1, (len(items)+perPage-1)/perPage)
+
+	start := min((n-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+	page = items[start:end]
+	if page == nil {
+		page = []T{}
+	}
+	return page, n, pages, perPage
+}
+
+// writeMetaPage writes a timetracking/projects style page: { key: [...], meta: {...} }.
+func writeMetaPage[T any](w http.ResponseWriter, r *http.Request, key string, items []T) {
+	page, n, pages, perPage := paginate(items, r)
+	writeJSON(w, http.StatusOK, map[string]any{
+		key: page,
+		"meta": map[string]any{
+			"page":     n,
+			"pages":    pages,
+			"per_page": perPage,
+			"total":    len(items),
+		},
+	})
+}
+
+// accountingError writes an accounting-style error envelope.
+func accountingError(w http.ResponseWriter, status int, field string, errno int, message string) {
+	e := map[string]any{"errno": errno, "message": message}
+	if field != "" {
+		e["field"] = field
+	}
+	writeJSON(w, status, map[string]any{
+		"response": map[string]any{"errors": []any{e}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,97 @@
+package fakefb
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hev/freshtime/internal/api"
+)
+
+func newTestClient(t *testing.T, s *Server) *api.HttpClient {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	origBase := api.BaseURL
+	api.BaseURL = srv.URL
+	t.Cleanup(func() { api.BaseURL = origBase })
+
+	return api.NewHttpClient("test-token")
+}
+
+func TestListClientsPaginates(t *testing.T) {
+	s := New()
+	for range 150 {
+		s.AddClient(Client{Organization: "Client"})
+	}
+	c := newTestClient(t, s)
+
+	clients, err := api.ListClients(c, s.AccountID)
+	if err != nil {
+		t.Fatalf("ListClients: %v", err)
+	}
+	if len(clients) != 150 {
+		t.Errorf("expected 150 clients across pages, got %d", len(clients))
+	}
+}
+
+func TestTimeEntryFilters(t *testing.T) {
+	s := New()
+	client := s.AddClient(Client{Organization: "Acme"})
+	s.AddTimeEntry(TimeEntry{ClientID: client, Duration: 3600, StartedAt: "2026-02-09T09:00:00Z", Billable: true})
+	s.AddTimeEntry(TimeEntry{ClientID: client, Duration: 3600, StartedAt: "2026-02-10T09:00:00Z", Billable: true, Billed: true})
+	s.AddTimeEntry(TimeEntry{ClientID: client, Duration: 3600, StartedAt: "2026-02-20T09:00:00Z", Billable: false})
+	c := newTestClient(t, s)
+
+	week, err := api.ListTimeEntries(c, s.BusinessID, "2026-02-09", "2026-02-13")
+	if err != nil {
+		t.Fatalf("ListTimeEntries: %v", err)
+	}
+	if len(week) != 2 {
+		t.Errorf("expected 2 entries in range, got %d", len(week))
+	}
+
+	unbilled, err := api.ListUnbilledEntries(c, s.BusinessID, client)
+	if err != nil {
+		t.Fatalf("ListUnbilledEntries: %v", err)
+	}
+	if len(unbilled) != 1 {
+		t.Errorf("expected 1 unbilled billable entry, got %d", len(unbilled))
+	}
+}
+
+func TestCreateAndBillTimeEntry(t *testing.T) {
+	s := New()
+	client := s.AddClient(Client{Organization: "Acme"})
+	c := newTestClient(t, s)
+
+	entry, err := api.CreateTimeEntry(c, s.BusinessID, api.CreateTimeEntryRequest{
+		ClientID:  client,
+		Duration:  1800,
+		Note:      "Standup",
+		Billable:  true,
+		StartedAt: "2026-02-09T09:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("CreateTimeEntry: %v", err)
+	}
+	if err := api.MarkEntriesAsBilled(c, s.BusinessID, []api.TimeEntry{*entry}); err != nil {
+		t.Fatalf("MarkEntriesAsBilled: %v", err)
+	}
+
+	stored := s.TimeEntries()
+	if len(stored) != 1 || !stored[0].Billed || stored[0].Note != "Standup" {
+		t.Errorf("unexpected stored entries: %+v", stored)
+	}
+}
+
+func TestRejectsMissingToken(t *testing.T) {
+	s := New()
+	c := newTestClient(t, s)
+	c.SetTokenSource(api.StaticTokenSource(""))
+
+	_, err := api.GetIdentity(c)
+	if _, ok := err.(*api.AuthError); !ok {
+		t.Fatalf("expected *api.AuthError, got %T (%v)", err, err)
+	}
+}
@@ -0,0 +1,81 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// EntryRow is a single time entry with its client, project and service
+// names resolved.
+type EntryRow struct {
+	ID        int     `json:"id"`
+	Date      string  `json:"date"`
+	Duration  int     `json:"duration"` // seconds
+	Hours     float64 `json:"hours"`
+	ClientID  int     `json:"clientId"`
+	Client    string  `json:"client"`
+	ProjectID int     `json:"projectId,omitempty"`
+	Project   string  `json:"project,omitempty"`
+	ServiceID int     `json:"serviceId,omitempty"`
+	Service   string  `json:"service,omitempty"`
+	Billable  bool    `json:"billable"`
+	Billed    bool    `json:"billed"`
+	Note      string  `json:"note"`
+}
+
+// status describes whether the entry is billed, waiting to be billed, or not
+// billable at all.
+func (r EntryRow) status() string {
+	switch {
+	case r.Billed:
+		return "billed"
+	case r.Billable:
+		return "unbilled"
+	}
+	return "non-billable"
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// EntriesTable renders time entries as a text table with a total row.
+func EntriesTable(rows []EntryRow) string {
+	if len(rows) == 0 {
+		return "No time entries found."
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDate\tHours\tClient\tProject\tStatus\tNote")
+	var total float64
+	for _, r := range rows {
+		project := r.Project
+		if project == "" {
+			project = "—"
+		}
+		note := strings.Join(strings.Fields(r.Note), " ")
+		fmt.Fprintf(w, "%d\t%s\t%.2f\t%s\t%s\t%s\t%s\n",
+			r.ID, r.Date, r.Hours, truncate(r.Client, 20), truncate(project, 20), r.status(), truncate(note, 50))
+		total += r.Hours
+	}
+	w.Flush()
+	fmt.Fprintf(&b, "\n%d entries, %.2fh", len(rows), total)
+	return b.String()
+}
+
+// EntriesJSON renders time entries as an indented JSON array.
+func EntriesJSON(rows []EntryRow) string {
+	if rows == nil {
+		rows = []EntryRow{}
+	}
+	data, _ := json.MarshalIndent(rows, "", "  ")
+	return string(data)
+}
@@ -126,3 +126,21 @@ func TestJSON(t *testing.T) {
 		t.Errorf("client name = %q, want %q", parsed.Clients[0].Name, "Acme Corp")
 	}
 }
+
+func TestEntriesTable(t *testing.T) {
+	out := EntriesTable([]EntryRow{
+		{ID: 7, Date: "2026-02-10", Hours: 1.5, Client: "Acme Corp", Project: "Website", Billable: true, Note: "Review\nPR"},
+		{ID: 8, Date: "2026-02-09", Hours: 0.25, Client: "Globex", Billed: true, Note: strings.Repeat("x", 60)},
+	})
+	for _, want := range []string{"Acme Corp", "Website", "unbilled", "billed", "Review PR", strings.Repeat("x", 49) + "…", "2 entries, 1.75h"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+	if got := EntriesTable(nil); got != "No time entries found." {
+		t.Errorf("empty table = %q", got)
+	}
+	if got := EntriesJSON(nil); got != "[]" {
+		t.Errorf("empty JSON = %q", got)
+	}
+}
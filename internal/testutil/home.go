@@ -0,0 +1,18 @@
+// Package testutil holds helpers shared by the tests of several packages.
+package testutil
+
+import "testing"
+
+// SetHome points HOME at a new temporary directory and clears the
+// variables that would otherwise take precedence over it, so a test never
+// reads or writes the developer's real config, credentials or timer. It
+// returns the new home directory.
+func SetHome(t testing.TB) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, env := range []string{"XDG_CONFIG_HOME", "XDG_STATE_HOME", "FRESHTIME_CONFIG"} {
+		t.Setenv(env, "")
+	}
+	return home
+}